@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/mirpo/chopdoc/config"
 	"github.com/mirpo/chopdoc/runner"
@@ -14,22 +17,71 @@ var (
 	commit  string = "commit"
 )
 
+// stringList is a flag which can be given several times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
 	cfg := config.NewConfig()
 
 	var ver bool
 	flag.BoolVar(&ver, "version", false, "Get current version of chopdoc")
-	flag.StringVar(&cfg.InputFile, "input", "", "Input file path")
-	flag.StringVar(&cfg.OutputFile, "output", "", "Output file path (must end with .jsonl)")
-	flag.IntVar(&cfg.ChunkSize, "size", 1000, "Chunk size in characters")
-	flag.IntVar(&cfg.Overlap, "overlap", 0, "Overlap size in characters")
-	method := flag.String("method", string(config.Char), "Default chunking method: char")
+	flag.Var((*stringList)(&cfg.Inputs), "input", "Input file, directory or glob pattern (e.g. 'docs/**/*.md'), can be repeated")
+	flag.Var((*stringList)(&cfg.Include), "include", "Only chunk files matching this pattern in directory and glob inputs, can be repeated")
+	flag.Var((*stringList)(&cfg.Exclude), "exclude", "Skip files and directories matching this pattern in directory and glob inputs, can be repeated")
+	inputFormat := flag.String("input-format", string(config.InputText), "Input format: text (documents), jsonl (records whose -text-field is chunked), csv, tsv (rows rendered with -text-columns)")
+	flag.StringVar(&cfg.TextField, "text-field", "text", "Field of jsonl records to chunk, other fields are added to chunk metadata")
+	flag.StringVar(&cfg.TextColumns, "text-columns", "", "Template rendering csv rows into text, e.g. 'Q: {question}\\nA: {answer}', other columns are added to chunk metadata")
+	flag.StringVar(&cfg.GroupBy, "group-by", "", "Column of csv rows, rows with the same value are chunked as one document")
+	flag.BoolVar(&cfg.GitIgnore, "gitignore", true, "Honor .gitignore files in directory and glob inputs")
+	flag.StringVar(&cfg.OutputFile, "output", "", "Output file path, its extension picks the output format: .jsonl, .json, .csv, .parquet, .sqlite")
+	flag.StringVar(&cfg.OutputDir, "output-dir", "", "Output directory, one output file is written per input")
+	format := flag.String("format", "", "Output format: jsonl, json, csv, parquet, sqlite (default from -output extension, else jsonl)")
+	schema := flag.String("schema", string(config.SchemaChopdoc), "Record schema of jsonl and json output: chopdoc, langchain, llamaindex, haystack, custom")
+	flag.StringVar(&cfg.RecordTemplate, "template", "", "Go text/template rendering a JSON record per chunk for custom schema, e.g. '{\"text\": {{json .Text}}, \"id\": {{json .ID}}}'")
+	flag.IntVar(&cfg.Workers, "workers", 1, "Number of inputs chopped in parallel")
+	flag.BoolVar(&cfg.Unordered, "unordered", false, "Write chunks of parallel inputs as soon as they are ready instead of in input order")
+	flag.IntVar(&cfg.ChunkSize, "size", 1000, "Chunk size, measured in units of the method or -length-unit")
+	flag.IntVar(&cfg.Overlap, "overlap", 0, "Overlap size, measured in units of the method or -length-unit")
+	method := flag.String("method", string(config.Char), "Chunking method: char, word, sentence, recursive, markdown, html, token, table, auto")
+	flag.StringVar(&cfg.MethodMapList, "method-map", "", "Extension to method mapping for auto method, e.g. '.txt=sentence,.pyi=recursive:python'")
 	clean := flag.String("clean", "none", "Cleaning mode: none, normal, aggressive")
+	flag.BoolVar(&cfg.Positions, "positions", false, "Include source offsets and line numbers of every chunk in output (default false)")
+	idScheme := flag.String("id-scheme", string(config.IDNone), "Chunk ID scheme, adds id and sha256 fields to output: none, uuidv5, sha256, ordinal")
+	lengthUnit := flag.String("length-unit", "", "Unit of -size and -overlap for recursive, sentence, markdown and html methods: runes, bytes, words, tokens")
 
 	// used only in markdown chopper
-	flag.StringVar(&cfg.MarkdownHeader, "headers", "1-6", "Header levels to use for markdown method (e.g. 1-6, 2-4)")
-	flag.BoolVar(&cfg.StripHeaders, "strip-headers", false, "Remove headers from content (default false, markdown method only)")
-	flag.BoolVar(&cfg.AddMetadata, "add-metadata", false, "Include header metadata in output (default false, markdown method only)")
+	flag.StringVar(&cfg.MarkdownHeader, "headers", "1-6", "Header levels to use for markdown and html methods (e.g. 1-6, 2-4)")
+	flag.BoolVar(&cfg.StripHeaders, "strip-headers", false, "Remove headers from content (default false, markdown and html methods only)")
+	flag.BoolVar(&cfg.AddMetadata, "add-metadata", false, "Include header metadata in output (default false, markdown and html methods only)")
+	flag.BoolVar(&cfg.SplitSections, "split-sections", false, "Split sections longer than -size with recursive method, keeping header metadata (default false, markdown and html methods only)")
+	flag.BoolVar(&cfg.MergeSections, "merge-sections", false, "Merge small adjacent sibling sections up to -size (default false, markdown and html methods only)")
+
+	// used in recursive chopper and markdown chopper with -split-sections
+	flag.StringVar(&cfg.SeparatorList, "separators", "", "Separators for recursive method, as comma separated (e.g. '\\n\\n,\\n, ') or JSON list")
+	flag.BoolVar(&cfg.SeparatorRegex, "separator-regex", false, "Treat separators as regular expressions (default false, recursive method only)")
+	keepSeparator := flag.String("keep-separator", "start", "Where to keep separators in recursive chunks: start, end, none")
+	flag.StringVar(&cfg.Language, "language", "", "Separator preset for recursive method: python, go, js, markdown, latex, html")
+
+	// used only in token chopper and tokens length unit
+	flag.StringVar(&cfg.Tokenizer, "tokenizer", "cl100k_base", "Tokenizer encoding for token method and tokens length unit: cl100k_base, o200k_base, p50k_base, r50k_base")
+
+	// used only with -embed
+	flag.BoolVar(&cfg.Embed, "embed", false, "Add embeddings of chunks to output from an OpenAI compatible embeddings API, its key is read from OPENAI_API_KEY (default false)")
+	flag.StringVar(&cfg.EmbedURL, "embed-url", cfg.EmbedURL, "Base URL of the embeddings API, e.g. http://localhost:11434/v1 for Ollama")
+	flag.StringVar(&cfg.EmbedModel, "embed-model", cfg.EmbedModel, "Embedding model")
+	flag.IntVar(&cfg.EmbedBatchSize, "embed-batch", cfg.EmbedBatchSize, "Number of chunks embedded per request")
+	flag.IntVar(&cfg.EmbedWorkers, "embed-workers", cfg.EmbedWorkers, "Number of embedding requests in flight")
+	flag.Float64Var(&cfg.EmbedRate, "embed-rate", 0, "Maximum embedding requests per second, 0 for no limit")
+	flag.IntVar(&cfg.EmbedRetries, "embed-retries", cfg.EmbedRetries, "Retries of rate limited and failed embedding requests, with exponential backoff")
 
 	flag.Parse()
 
@@ -43,18 +95,32 @@ func main() {
 		slog.Error("failed to check stdin", "err", err)
 		os.Exit(1)
 	}
-	cfg.Piped = (stat.Mode()&os.ModeCharDevice) == 0 && cfg.InputFile == ""
+	cfg.Piped = (stat.Mode()&os.ModeCharDevice) == 0 && len(cfg.Inputs) == 0
 	cfg.CleaningMode = config.CleaningMode(*clean)
 	cfg.Method = config.ChunkMethod(*method)
+	cfg.LengthUnit = config.LengthUnit(*lengthUnit)
+	cfg.KeepSeparator = config.KeepSeparator(*keepSeparator)
+	cfg.IDScheme = config.IDScheme(*idScheme)
+	cfg.InputFormat = config.InputFormat(*inputFormat)
+	cfg.OutputFormat = config.OutputFormat(*format)
+	cfg.Schema = config.Schema(*schema)
+	cfg.EmbedAPIKey = os.Getenv("OPENAI_API_KEY")
 
 	if err := cfg.Validate(); err != nil {
 		slog.Error("failed to validate config", "err", err)
 		os.Exit(1)
 	}
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	r := runner.NewRunner(cfg)
-	if err := r.Run(); err != nil {
+	if err := r.RunContext(ctx); err != nil {
 		slog.Error("execution error", "err", err)
+		stop()
 		os.Exit(1)
 	}
 }
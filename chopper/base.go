@@ -2,8 +2,7 @@ package chopper
 
 import (
 	"bufio"
-	"encoding/json"
-	"fmt"
+	"io"
 	"strings"
 
 	"github.com/mirpo/chopdoc/cleaner"
@@ -11,26 +10,61 @@ import (
 )
 
 type BaseChopper struct {
-	cfg     *config.Config
-	encoder *json.Encoder
-	scanner *bufio.Scanner
+	cfg       *config.Config
+	sink      ChunkSink
+	scanner   *bufio.Scanner
+	length    LengthFunc
+	positions *positionTracker
+	// page spans of paged inputs
+	pages []span
+	// number of chunks written so far
+	ordinal int
+}
+
+// trackPositions wraps r to compute chunk positions when they are enabled,
+// pages of a PagedReader are recorded for page metadata.
+func (b *BaseChopper) trackPositions(r io.Reader) io.Reader {
+	if paged, ok := r.(*PagedReader); ok && len(paged.pages) > 0 {
+		b.pages = paged.pages
+	}
+
+	if !b.cfg.Positions {
+		return r
+	}
+
+	b.positions = &positionTracker{reader: r}
+	return b.positions
 }
 
 func (b *BaseChopper) cleanChunk(chunk string) string {
 	return cleaner.Clean(chunk, b.cfg.CleaningMode)
 }
 
-func (b *BaseChopper) writeChunk(chunk string) error {
-	b.encoder.SetEscapeHTML(false)
+// writeChunk writes the chunk which spans input bytes [start, end).
+func (b *BaseChopper) writeChunk(chunk string, start, end int, metadata map[string]any) error {
 	chunk = b.cleanChunk(chunk)
 
 	if len(strings.TrimSpace(chunk)) == 0 {
 		return nil
 	}
 
-	if err := b.encoder.Encode(Chunk{Text: chunk}); err != nil {
-		return fmt.Errorf("failed to write chunk: %w", err)
+	if b.pages != nil {
+		metadata = pageMetadata(metadata, b.pages, start, end)
+	}
+
+	out := Chunk{Source: b.cfg.InputFile, Text: chunk, Metadata: metadata}
+	if b.positions != nil {
+		position, err := b.positions.locate(start, end)
+		if err != nil {
+			return err
+		}
+		out.Position = position
+	}
+	if b.cfg.IDScheme != "" && b.cfg.IDScheme != config.IDNone {
+		out.ID = chunkID(b.cfg.IDScheme, b.cfg.InputFile, b.ordinal, chunk)
+		out.SHA256 = contentHash(chunk)
 	}
+	b.ordinal++
 
-	return nil
+	return b.sink.WriteChunk(out)
 }
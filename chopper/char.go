@@ -2,54 +2,75 @@ package chopper
 
 import (
 	"bufio"
-	"encoding/json"
+	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mirpo/chopdoc/config"
 )
 
 type CharChopper struct {
 	BaseChopper
+	// input width of the last scanned rune, invalid UTF-8 bytes are scanned
+	// as U+FFFD
+	width int
 }
 
-func NewCharChopper(cfg *config.Config, rw *bufio.ReadWriter) *CharChopper {
-	scanner := bufio.NewScanner(rw.Reader)
-	scanner.Split(bufio.ScanRunes)
-
-	return &CharChopper{
+func NewCharChopper(cfg *config.Config, r io.Reader, sink ChunkSink) *CharChopper {
+	c := &CharChopper{
 		BaseChopper: BaseChopper{
-			cfg:     cfg,
-			encoder: json.NewEncoder(rw.Writer),
-			scanner: scanner,
+			cfg:  cfg,
+			sink: sink,
 		},
 	}
+
+	c.scanner = bufio.NewScanner(c.trackPositions(r))
+	c.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanRunes(data, atEOF)
+		if token != nil {
+			c.width = advance
+		}
+		return advance, token, err
+	})
+
+	return c
 }
 
 func (c *CharChopper) scanInput() error {
 	var builder strings.Builder
 	builder.Grow(c.cfg.ChunkSize)
 	step := c.cfg.ChunkSize - c.cfg.Overlap
+	// offsets[i] is the input offset of byte i of the builder, replaced
+	// invalid bytes make them differ
+	offsets := make([]int, 0, c.cfg.ChunkSize+utf8.UTFMax)
+	input := 0
 
 	for c.scanner.Scan() {
-		builder.WriteString(c.scanner.Text())
+		text := c.scanner.Text()
+		for i := range len(text) {
+			offsets = append(offsets, input+min(i, c.width))
+		}
+		input += c.width
+		builder.WriteString(text)
 
 		if builder.Len() >= c.cfg.ChunkSize {
 			chunk := builder.String()
-			if err := c.writeChunk(chunk); err != nil {
+			if err := c.writeChunk(chunk, offsets[0], input, nil); err != nil {
 				return err
 			}
 
+			builder.Reset()
 			if step > len(chunk) {
-				builder.Reset()
+				offsets = offsets[:0]
 			} else {
-				builder.Reset()
 				builder.WriteString(chunk[step:])
+				offsets = append(offsets[:0], offsets[step:]...)
 			}
 		}
 	}
 
 	if builder.Len() > 0 {
-		return c.writeChunk(builder.String())
+		return c.writeChunk(builder.String(), offsets[0], input, nil)
 	}
 
 	return c.scanner.Err()
@@ -0,0 +1,43 @@
+package chopper
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/mirpo/chopdoc/decoder"
+)
+
+// HTMLChopper converts HTML into markdown and chops it like MarkdownChopper,
+// h1-h6 become markdown headers, tables and lists are kept as markdown text.
+type HTMLChopper struct {
+	*MarkdownChopper
+}
+
+func NewHTMLChopper(cfg *config.Config, r io.Reader, sink ChunkSink) (*HTMLChopper, error) {
+	markdown, err := NewMarkdownChopper(cfg, &htmlReader{reader: r}, sink)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTMLChopper{MarkdownChopper: markdown}, nil
+}
+
+// htmlReader reads the markdown text of the HTML document read from reader,
+// the document is converted on the first read.
+type htmlReader struct {
+	reader io.Reader
+	text   *strings.Reader
+}
+
+func (h *htmlReader) Read(p []byte) (int, error) {
+	if h.text == nil {
+		text, err := decoder.HTMLToMarkdown(h.reader)
+		if err != nil {
+			return 0, err
+		}
+		h.text = strings.NewReader(text)
+	}
+
+	return h.text.Read(p)
+}
@@ -0,0 +1,45 @@
+package chopper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLChopper(t *testing.T) {
+	input := `<!DOCTYPE html>
+<html><head><title>Docs</title></head><body>
+<nav><ul><li>Home</li><li>Guide</li></ul></nav>
+<h1>Guide</h1>
+<p>Welcome.</p>
+<h2>Install</h2>
+<ol><li>Download</li><li>Run</li></ol>
+<h2>Options</h2>
+<table><tr><th>Flag</th><th>Use</th></tr><tr><td>-size</td><td>chunk size</td></tr></table>
+</body></html>`
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1, 2},
+		ChunkSize:      100,
+		AddMetadata:    true,
+	}
+
+	var chunks []Chunk
+	sink := SinkFunc(func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+
+	chopper, err := NewHTMLChopper(cfg, strings.NewReader(input), sink)
+	require.NoError(t, err)
+	require.NoError(t, chopper.Chop())
+
+	assert.Equal(t, []Chunk{
+		{Text: "# Guide\n\nWelcome.\n\n", Metadata: headerMetadata("Guide")},
+		{Text: "## Install\n\n1. Download\n2. Run\n\n", Metadata: headerMetadata("Guide", "Install")},
+		{Text: "## Options\n\n| Flag | Use |\n| --- | --- |\n| -size | chunk size |\n", Metadata: headerMetadata("Guide", "Options")},
+	}, chunks)
+}
@@ -0,0 +1,61 @@
+package chopper
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+// urlNamespace is the RFC 9562 namespace for URLs, chunk IDs live in the
+// namespace derived from it and the project URL.
+var (
+	urlNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	idNamespace  = uuidV5(urlNamespace, "https://github.com/mirpo/chopdoc")
+)
+
+// chunkID derives the ID of a chunk from its source, its ordinal in the
+// source and its text, so unchanged documents get the same IDs on every run.
+func chunkID(scheme config.IDScheme, source string, ordinal int, text string) string {
+	switch scheme {
+	case config.IDOrdinal:
+		if source == "" {
+			return strconv.Itoa(ordinal)
+		}
+		return source + "#" + strconv.Itoa(ordinal)
+	case config.IDSHA256:
+		return contentHash(idName(source, ordinal, text))
+	case config.IDUUIDv5:
+		return formatUUID(uuidV5(idNamespace, idName(source, ordinal, text)))
+	}
+	return ""
+}
+
+func idName(source string, ordinal int, text string) string {
+	return source + "\x00" + strconv.Itoa(ordinal) + "\x00" + text
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func uuidV5(namespace [16]byte, name string) [16]byte {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	var uuid [16]byte
+	copy(uuid[:], h.Sum(nil))
+	uuid[6] = uuid[6]&0x0f | 0x50
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return uuid
+}
+
+func formatUUID(uuid [16]byte) string {
+	s := hex.EncodeToString(uuid[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
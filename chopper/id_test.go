@@ -0,0 +1,114 @@
+package chopper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUIDv5(t *testing.T) {
+	dnsNamespace := [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	assert.Equal(t, "886313e1-3b8a-5372-9b90-0c9aee199e5d", formatUUID(uuidV5(dnsNamespace, "python.org")))
+	assert.Equal(t, "95a99e66-762b-5249-8255-92e44b782138", formatUUID(idNamespace))
+}
+
+func TestChunkIDs(t *testing.T) {
+	textHashes := []string{
+		"8ab63e29a4ba14e4e1688f9c15e5af90895421358c945b0431f85d66977bd3d2",
+		"8b5b9db0c13db24256c829aa364aa90c6d2eba318b9232a4ab9313b954d3555f",
+	}
+
+	tests := []struct {
+		name    string
+		scheme  config.IDScheme
+		source  string
+		wantIDs []string
+	}{
+		{
+			name:    "none",
+			scheme:  config.IDNone,
+			source:  "doc.txt",
+			wantIDs: []string{"", ""},
+		},
+		{
+			name:    "ordinal",
+			scheme:  config.IDOrdinal,
+			source:  "doc.txt",
+			wantIDs: []string{"doc.txt#0", "doc.txt#1"},
+		},
+		{
+			name:    "ordinal without source",
+			scheme:  config.IDOrdinal,
+			wantIDs: []string{"0", "1"},
+		},
+		{
+			name:   "sha256",
+			scheme: config.IDSHA256,
+			source: "doc.txt",
+			wantIDs: []string{
+				"eb53b6f390afc7c5d30d9fc8e0a85304d7f0757ba4ddbf62d2cbd160f8cf7ccf",
+				"0fb799ab83ce915fcca5405aa5b5d3894cdad9806d52d730babf4c42e5a791cd",
+			},
+		},
+		{
+			name:    "uuidv5",
+			scheme:  config.IDUUIDv5,
+			source:  "doc.txt",
+			wantIDs: []string{"807f1eae-b0d9-5bde-8ea0-42df19648bb4", "199be670-69d4-5f7d-acb9-b85a33198e7f"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{InputFile: tt.source, ChunkSize: 2, IDScheme: tt.scheme}
+
+			var ids, hashes []string
+			sink := SinkFunc(func(chunk Chunk) error {
+				ids = append(ids, chunk.ID)
+				hashes = append(hashes, chunk.SHA256)
+				return nil
+			})
+
+			require.NoError(t, NewWordChopper(cfg, strings.NewReader("one two three"), sink).Chop())
+
+			assert.Equal(t, tt.wantIDs, ids)
+			if tt.scheme == config.IDNone {
+				assert.Equal(t, []string{"", ""}, hashes)
+			} else {
+				assert.Equal(t, textHashes, hashes)
+			}
+		})
+	}
+}
+
+func TestChunkIDsAreStable(t *testing.T) {
+	chop := func(input string) []Chunk {
+		cfg := &config.Config{InputFile: "doc.md", MarkdownLevels: []int{1}, IDScheme: config.IDUUIDv5}
+
+		var chunks []Chunk
+		sink := SinkFunc(func(chunk Chunk) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+
+		chopper, err := NewMarkdownChopper(cfg, strings.NewReader(input), sink)
+		require.NoError(t, err)
+		require.NoError(t, chopper.Chop())
+
+		return chunks
+	}
+
+	before := chop("# A\nfirst\n# B\nsecond\n# C\nthird\n")
+	after := chop("# A\nfirst\n# B\nsecond, edited\n# C\nthird\n")
+
+	require.Len(t, after, 3)
+	assert.Equal(t, before[0].ID, after[0].ID)
+	assert.NotEqual(t, before[1].ID, after[1].ID)
+	assert.NotEqual(t, before[1].SHA256, after[1].SHA256)
+	assert.Equal(t, before[2].ID, after[2].ID)
+	assert.Equal(t, before[2].SHA256, after[2].SHA256)
+}
@@ -0,0 +1,31 @@
+package chopper
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/mirpo/chopdoc/tokenizer"
+)
+
+// LengthFunc measures text in the unit used by ChunkSize and Overlap.
+type LengthFunc func(text string) int
+
+func NewLengthFunc(unit config.LengthUnit, tokenizerName string) (LengthFunc, error) {
+	switch unit {
+	case config.Runes, "":
+		return utf8.RuneCountInString, nil
+	case config.Bytes:
+		return func(text string) int { return len(text) }, nil
+	case config.Words:
+		return func(text string) int { return len(strings.Fields(text)) }, nil
+	case config.Tokens:
+		tok, err := tokenizer.Get(tokenizerName)
+		if err != nil {
+			return nil, err
+		}
+		return tok.Count, nil
+	}
+	return nil, fmt.Errorf("unsupported length unit: %s", unit)
+}
@@ -0,0 +1,39 @@
+package chopper
+
+import (
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLengthFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		unit    config.LengthUnit
+		text    string
+		want    int
+		wantErr string
+	}{
+		{name: "default is runes", unit: "", text: "héllo 世界", want: 8},
+		{name: "runes", unit: config.Runes, text: "héllo 世界", want: 8},
+		{name: "bytes", unit: config.Bytes, text: "héllo 世界", want: 13},
+		{name: "words", unit: config.Words, text: " one  two\nthree ", want: 3},
+		{name: "tokens", unit: config.Tokens, text: "hello world", want: 2},
+		{name: "unknown", unit: config.LengthUnit("lines"), wantErr: "unsupported length unit: lines"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, err := NewLengthFunc(tt.unit, "cl100k_base")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, length(tt.text))
+		})
+	}
+}
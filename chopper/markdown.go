@@ -2,26 +2,132 @@ package chopper
 
 import (
 	"bufio"
-	"encoding/json"
+	"bytes"
 	"fmt"
-	"regexp"
+	"io"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/mirpo/chopdoc/config"
+	"gopkg.in/yaml.v3"
 )
 
 type header struct {
-	Pattern string
-	Name    string
-	Level   int
+	Name  string
+	Level int
 }
 
 type MarkdownChopper struct {
 	BaseChopper
-	headers   []header
-	headerRgx *regexp.Regexp
-	metadata  map[string]string
+	headers     []header
+	blocks      blockScanner
+	stack       []heading
+	frontMatter map[string]any
+	splitter    *textSplitter
+	pending     *section
+	builder     sectionBuilder
+	reader      *bufio.Reader
+	// input offset of the next line
+	offset int
+	// offset of the current paragraph in the builder buffer, or -1
+	paragraph int
+	// last chunk is held back so whitespace which can't be a chunk on its own
+	// is appended to it, leading whitespace is prepended to the first chunk
+	held    *heldChunk
+	leading piece
+}
+
+type heldChunk struct {
+	chunk    piece
+	headings []heading
+}
+
+// section is the text under a header. Fenced code blocks are kept in parts of
+// their own, so they are never split unless a single block is too long.
+type section struct {
+	parts    []piece
+	headings []heading
+	// parents and level of the section header, used to find siblings
+	parents []heading
+	level   int
+}
+
+// heading is an entry of the header stack, from the document title down to
+// the header of the current section.
+type heading struct {
+	level int
+	title string
+}
+
+func (s *section) text() string {
+	var text strings.Builder
+	for _, part := range s.parts {
+		text.WriteString(part.text)
+	}
+	return text.String()
+}
+
+// locate maps offsets of p in the section text to input offsets.
+func (s *section) locate(p piece) piece {
+	located := p
+	offset := 0
+	for _, part := range s.parts {
+		n := len(part.text)
+		if p.start >= offset && p.start < offset+n {
+			located.start = part.start + p.start - offset
+		}
+		if p.end > offset && p.end <= offset+n {
+			located.end = part.start + p.end - offset
+		}
+		offset += n
+	}
+	return located
+}
+
+type sectionBuilder struct {
+	parts  []piece
+	buffer bytes.Buffer
+	// input offset of the buffer
+	start int
+}
+
+func (b *sectionBuilder) write(line string, offset int) {
+	if b.buffer.Len() == 0 {
+		b.start = offset
+	}
+	b.buffer.WriteString(line)
+}
+
+func (b *sectionBuilder) cut() {
+	if b.buffer.Len() > 0 {
+		end := b.start + b.buffer.Len()
+		b.parts = append(b.parts, piece{text: b.buffer.String(), start: b.start, end: end})
+		b.start = end
+		b.buffer.Reset()
+	}
+}
+
+func (b *sectionBuilder) empty() bool {
+	return len(b.parts) == 0 && b.buffer.Len() == 0
+}
+
+func (b *sectionBuilder) reset() []piece {
+	b.cut()
+	parts := b.parts
+	b.parts = nil
+	return parts
+}
+
+// splitAt returns the parts before offset of the buffer and keeps the rest.
+func (b *sectionBuilder) splitAt(offset int) []piece {
+	rest := string(b.buffer.Bytes()[offset:])
+	b.buffer.Truncate(offset)
+	parts := b.reset()
+	b.buffer.WriteString(rest)
+	return parts
 }
 
 func createHeaders(levels []int) []header {
@@ -29,103 +135,384 @@ func createHeaders(levels []int) []header {
 
 	for i, level := range levels {
 		headers[i] = header{
-			Pattern: strings.Repeat("#", level),
-			Name:    "Header " + strconv.Itoa(level),
-			Level:   level,
+			Name:  "Header " + strconv.Itoa(level),
+			Level: level,
 		}
 	}
 
 	return headers
 }
 
-func createHeaderRegex(headers []header) *regexp.Regexp {
-	patterns := make([]string, len(headers))
-	for i, h := range headers {
-		patterns[i] = "^(" + regexp.QuoteMeta(h.Pattern) + " )"
+func NewMarkdownChopper(cfg *config.Config, r io.Reader, sink ChunkSink) (*MarkdownChopper, error) {
+	length, err := NewLengthFunc(cfg.LengthUnit, cfg.Tokenizer)
+	if err != nil {
+		return nil, err
 	}
-	return regexp.MustCompile(strings.Join(patterns, "|"))
-}
 
-func NewMarkdownChopper(cfg *config.Config, rw *bufio.ReadWriter) *MarkdownChopper {
-	headers := createHeaders(cfg.MarkdownLevels)
+	splitter, err := newTextSplitter(cfg, length)
+	if err != nil {
+		return nil, err
+	}
 
-	return &MarkdownChopper{
+	m := &MarkdownChopper{
 		BaseChopper: BaseChopper{
-			cfg:     cfg,
-			encoder: json.NewEncoder(rw.Writer),
-			scanner: bufio.NewScanner(rw.Reader),
+			cfg:    cfg,
+			sink:   sink,
+			length: length,
 		},
-		headers:   headers,
-		headerRgx: createHeaderRegex(headers),
-		metadata:  make(map[string]string),
+		headers:   createHeaders(cfg.MarkdownLevels),
+		splitter:  splitter,
+		paragraph: -1,
 	}
+	m.reader = bufio.NewReader(m.trackPositions(r))
+
+	return m, nil
 }
 
 func (m *MarkdownChopper) scanInput() error {
-	var buffer strings.Builder
+	lines, err := m.scanFrontMatter()
+	if err != nil {
+		return err
+	}
 
-	for m.scanner.Scan() {
-		line := m.scanner.Text()
-		if len(line) == 0 {
+	for _, line := range lines {
+		if err := m.processLine(line); err != nil {
+			return err
+		}
+	}
+
+	for {
+		line, err := m.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := m.processLine(line); err != nil {
+			return err
+		}
+	}
+
+	if !m.builder.empty() {
+		if err := m.addSection(m.builder.reset()); err != nil {
+			return err
+		}
+	}
+
+	if err := m.flushPending(); err != nil {
+		return err
+	}
+
+	return m.flushHeld()
+}
+
+// readLine returns the next line including its line ending, so chunks keep
+// the original text byte for byte.
+func (m *MarkdownChopper) readLine() (string, error) {
+	line, err := m.reader.ReadString('\n')
+	if err == io.EOF && len(line) > 0 {
+		return line, nil
+	}
+	return line, err
+}
+
+// scanFrontMatter parses YAML (---) or TOML (+++) front matter at the start of
+// the document. Lines that turn out not to be front matter, including ---
+// blocks which aren't a YAML mapping, are returned.
+func (m *MarkdownChopper) scanFrontMatter() ([]string, error) {
+	first, err := m.readLine()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := strings.TrimRight(first, " \t\r\n")
+	if delimiter != "---" && delimiter != "+++" {
+		return []string{first}, nil
+	}
+
+	lines := []string{first}
+	for {
+		line, err := m.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+
+		closing := strings.TrimRight(line, " \t\r\n")
+		if closing != delimiter && (delimiter != "---" || closing != "...") {
 			continue
 		}
 
-		if m.headerRgx.MatchString(line) {
-			if buffer.Len() > 0 {
-				if err := m.processBuffer(buffer.String()); err != nil {
-					return err
-				}
-				buffer.Reset()
+		content := strings.Join(lines[1:len(lines)-1], "")
+		frontMatter := make(map[string]any)
+		if delimiter == "---" {
+			// a leading thematic break or a block which isn't a YAML mapping
+			// is regular content
+			if err := yaml.Unmarshal([]byte(content), &frontMatter); err != nil {
+				return lines, nil
 			}
-			m.updateMetadata(line)
-
-			if m.cfg.StripHeaders {
-				continue
+		} else {
+			if _, err := toml.Decode(content, &frontMatter); err != nil {
+				return nil, fmt.Errorf("failed to parse TOML front matter: %w", err)
 			}
 		}
+		m.frontMatter = frontMatter
+		m.offset = len(strings.Join(lines, ""))
 
-		buffer.WriteString(line + "\n")
+		return nil, nil
 	}
 
-	if buffer.Len() > 0 {
-		if err := m.processBuffer(buffer.String()); err != nil {
-			return err
+	// never closed, so it's regular content
+	return lines, nil
+}
+
+func (m *MarkdownChopper) processLine(raw string) error {
+	line := strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+	info := m.blocks.scan(line)
+	offset := m.offset
+	m.offset += len(raw)
+
+	switch info.kind {
+	case headerLine:
+		if !m.isSplitLevel(info.level) {
+			break
 		}
+
+		if !m.builder.empty() {
+			if err := m.addSection(m.builder.reset()); err != nil {
+				return err
+			}
+		}
+		m.pushHeading(info.level, trimClosingHashes(info.title))
+
+		if m.cfg.StripHeaders {
+			return nil
+		}
+	case setextLine:
+		if !m.isSplitLevel(info.level) || m.paragraph < 0 {
+			break
+		}
+
+		// the header text was already written as a paragraph, it is moved
+		// to the new section
+		parts := m.builder.splitAt(m.paragraph)
+		if len(parts) > 0 {
+			if err := m.addSection(parts); err != nil {
+				return err
+			}
+		}
+		m.pushHeading(info.level, info.title)
+		m.paragraph = -1
+
+		if m.cfg.StripHeaders {
+			m.builder.buffer.Reset()
+			return nil
+		}
+	case textLine:
+		if m.paragraph < 0 {
+			m.paragraph = m.builder.buffer.Len()
+		}
+	case fenceOpenLine:
+		m.builder.cut()
 	}
 
-	return m.scanner.Err()
+	if info.kind != textLine {
+		m.paragraph = -1
+	}
+
+	m.builder.write(raw, offset)
+
+	if info.kind == fenceCloseLine {
+		m.builder.cut()
+	}
+
+	return nil
 }
 
-func (m *MarkdownChopper) updateMetadata(line string) {
+func (m *MarkdownChopper) isSplitLevel(level int) bool {
 	for _, header := range m.headers {
-		headerPrefix := header.Pattern + " "
-		if strings.HasPrefix(line, headerPrefix) {
-			m.metadata[header.Name] = strings.TrimPrefix(line, headerPrefix)
-			break
+		if header.Level == level {
+			return true
 		}
 	}
+	return false
 }
 
-func (m *MarkdownChopper) processBuffer(chunk string) error {
-	m.encoder.SetEscapeHTML(false)
-	chunk = m.cleanChunk(chunk)
+// pushHeading replaces headers of the same or deeper level with the new one.
+func (m *MarkdownChopper) pushHeading(level int, title string) {
+	for len(m.stack) > 0 && m.stack[len(m.stack)-1].level >= level {
+		m.stack = m.stack[:len(m.stack)-1]
+	}
+	m.stack = append(m.stack, heading{level: level, title: title})
+}
+
+// addSection emits the section, with merging enabled small sections are kept
+// pending until it's known whether the next sibling fits next to them.
+func (m *MarkdownChopper) addSection(parts []piece) error {
+	current := &section{parts: parts, headings: slices.Clone(m.stack)}
+	if len(current.headings) > 0 {
+		current.parents = current.headings[:len(current.headings)-1]
+		current.level = current.headings[len(current.headings)-1].level
+	}
+	if !m.cfg.MergeSections {
+		return m.processSection(current)
+	}
+
+	if m.pending != nil && m.canMerge(m.pending, current) {
+		m.pending = &section{
+			parts:    append(m.pending.parts, current.parts...),
+			headings: current.parents,
+			parents:  current.parents,
+			level:    current.level,
+		}
+		return nil
+	}
+
+	if err := m.flushPending(); err != nil {
+		return err
+	}
+	m.pending = current
 
-	if len(strings.TrimSpace(chunk)) == 0 {
+	return nil
+}
+
+func (m *MarkdownChopper) flushPending() error {
+	if m.pending == nil {
 		return nil
 	}
 
-	jsonlChunk := Chunk{Text: chunk}
-	if m.cfg.AddMetadata {
-		jsonlChunk.Metadata = m.metadata
+	pending := m.pending
+	m.pending = nil
+
+	return m.processSection(pending)
+}
+
+// canMerge reports whether b is a sibling of a, i.e. both sections start with
+// headers of the same level under the same parents, and both fit in a chunk.
+func (m *MarkdownChopper) canMerge(a, b *section) bool {
+	if a.level == 0 || a.level != b.level || !slices.Equal(a.parents, b.parents) {
+		return false
+	}
+
+	return m.length(a.text()+b.text()) <= m.cfg.ChunkSize
+}
+
+func (m *MarkdownChopper) processSection(s *section) error {
+	text := s.text()
+	if !m.cfg.SplitSections || m.length(text) <= m.cfg.ChunkSize {
+		return m.processBuffer(s.locate(piece{text: text, start: 0, end: len(text)}), s.headings)
+	}
+
+	for _, chunk := range m.splitSection(text, s.parts) {
+		if err := m.processBuffer(s.locate(chunk), s.headings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitSection merges section parts into chunks, only parts which are too long
+// on their own, including code blocks, are split with the recursive splitter.
+func (m *MarkdownChopper) splitSection(text string, parts []piece) []piece {
+	var chunks, good []piece
+	offset := 0
+	for _, part := range parts {
+		p := piece{text: part.text, start: offset, end: offset + len(part.text)}
+		offset = p.end
+
+		if m.length(part.text) < m.cfg.ChunkSize {
+			good = append(good, p)
+			continue
+		}
+
+		if len(good) > 0 {
+			chunks = append(chunks, m.splitter.mergePieces(text, 0, good)...)
+			good = nil
+		}
+		chunks = append(chunks, m.splitter.splitText(part.text, p.start, m.splitter.separators)...)
+	}
+
+	if len(good) > 0 {
+		chunks = append(chunks, m.splitter.mergePieces(text, 0, good)...)
+	}
+
+	return chunks
+}
+
+func (m *MarkdownChopper) processBuffer(chunk piece, headings []heading) error {
+	if strings.TrimSpace(chunk.text) == "" {
+		if m.held != nil {
+			m.held.chunk = joinPieces(m.held.chunk, chunk)
+		} else {
+			m.leading = joinPieces(m.leading, chunk)
+		}
+		return nil
 	}
 
-	if err := m.encoder.Encode(jsonlChunk); err != nil {
-		return fmt.Errorf("failed to write chunk: %w", err)
+	if err := m.flushHeld(); err != nil {
+		return err
 	}
+	m.held = &heldChunk{chunk: joinPieces(m.leading, chunk), headings: headings}
+	m.leading = piece{}
 
 	return nil
 }
 
+func joinPieces(a, b piece) piece {
+	if a.text == "" {
+		return b
+	}
+	return piece{text: a.text + b.text, start: a.start, end: b.end}
+}
+
+func (m *MarkdownChopper) flushHeld() error {
+	if m.held == nil {
+		return nil
+	}
+
+	held := m.held
+	m.held = nil
+
+	if !m.cfg.AddMetadata {
+		return m.writeChunk(held.chunk.text, held.chunk.start, held.chunk.end, nil)
+	}
+
+	return m.writeChunk(held.chunk.text, held.chunk.start, held.chunk.end, m.chunkMetadata(held.headings))
+}
+
+// chunkMetadata returns a fresh copy of the front matter merged with header
+// metadata: a "Header N" key per level, the breadcrumb of header titles and
+// the same breadcrumb joined into h_path.
+func (m *MarkdownChopper) chunkMetadata(headings []heading) map[string]any {
+	metadata := maps.Clone(m.frontMatter)
+	if metadata == nil {
+		metadata = make(map[string]any, len(headings)+2)
+	}
+
+	if len(headings) == 0 {
+		return metadata
+	}
+
+	breadcrumb := make([]string, len(headings))
+	for i, h := range headings {
+		metadata["Header "+strconv.Itoa(h.level)] = h.title
+		breadcrumb[i] = h.title
+	}
+	metadata["breadcrumb"] = breadcrumb
+	metadata["h_path"] = strings.Join(breadcrumb, " > ")
+
+	return metadata
+}
+
 func (m *MarkdownChopper) Chop() error {
 	return m.scanInput()
 }
@@ -0,0 +1,529 @@
+package chopper
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chopMarkdown(t *testing.T, cfg *config.Config, input string) []Chunk {
+	t.Helper()
+
+	var chunks []Chunk
+	sink := SinkFunc(func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+
+	chopper, err := NewMarkdownChopper(cfg, strings.NewReader(input), sink)
+	require.NoError(t, err)
+	require.NoError(t, chopper.Chop())
+
+	return chunks
+}
+
+func headerMetadata(titles ...string) map[string]any {
+	metadata := map[string]any{"breadcrumb": titles, "h_path": strings.Join(titles, " > ")}
+	for i, title := range titles {
+		metadata[fmt.Sprintf("Header %d", i+1)] = title
+	}
+	return metadata
+}
+
+func withMetadata(metadata, extra map[string]any) map[string]any {
+	maps.Copy(metadata, extra)
+	return metadata
+}
+
+func TestMarkdownSplitSections(t *testing.T) {
+	input := "# Title\nintro\n## Long\n" +
+		"first sentence of the long section.\n" +
+		"second sentence of the long section.\n" +
+		"third sentence of the long section.\n" +
+		"## Short\nshort text\n"
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1, 2},
+		ChunkSize:      80,
+		Overlap:        0,
+		AddMetadata:    true,
+		SplitSections:  true,
+	}
+
+	chunks := chopMarkdown(t, cfg, input)
+
+	long := headerMetadata("Title", "Long")
+	assert.Equal(t, []Chunk{
+		{Text: "# Title\nintro\n", Metadata: headerMetadata("Title")},
+		{Text: "## Long\nfirst sentence of the long section.\nsecond sentence of the long section.", Metadata: long},
+		{Text: "\nthird sentence of the long section.\n", Metadata: long},
+		{Text: "## Short\nshort text\n", Metadata: headerMetadata("Title", "Short")},
+	}, chunks)
+
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Text), cfg.ChunkSize)
+	}
+}
+
+func TestMarkdownSplitSectionsWithOverlap(t *testing.T) {
+	input := "# Title\none two three four five six seven eight\n"
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1},
+		ChunkSize:      4,
+		Overlap:        1,
+		LengthUnit:     config.Words,
+		StripHeaders:   true,
+		SplitSections:  true,
+		CleaningMode:   config.CleanTrim,
+	}
+
+	var got []string
+	for _, chunk := range chopMarkdown(t, cfg, input) {
+		got = append(got, chunk.Text)
+	}
+
+	assert.Equal(t, []string{"one two three four", "four five six seven", "seven eight"}, got)
+}
+
+func TestMarkdownMergeSections(t *testing.T) {
+	input := "# A\n## a1\none\n## a2\ntwo\n## a3\nthree\n"
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1, 2},
+		ChunkSize:      25,
+		AddMetadata:    true,
+		MergeSections:  true,
+	}
+
+	chunks := chopMarkdown(t, cfg, input)
+
+	assert.Equal(t, []Chunk{
+		{Text: "# A\n", Metadata: headerMetadata("A")},
+		{Text: "## a1\none\n## a2\ntwo\n", Metadata: headerMetadata("A")},
+		{Text: "## a3\nthree\n", Metadata: headerMetadata("A", "a3")},
+	}, chunks)
+}
+
+func TestMarkdownCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "comment in fenced code block",
+			input: "# Install\n```bash\n# install deps\nmake install\n```\n# Usage\ntext\n",
+			want:  []string{"# Install\n```bash\n# install deps\nmake install\n```\n", "# Usage\ntext\n"},
+		},
+		{
+			name:  "tilde fence closed by longer fence",
+			input: "# A\n~~~~python\n# comment\n~~~\n# still code\n~~~~~\n# B\n",
+			want:  []string{"# A\n~~~~python\n# comment\n~~~\n# still code\n~~~~~\n", "# B\n"},
+		},
+		{
+			name:  "backtick fence not closed by tildes",
+			input: "# A\n```\n# code\n~~~\n# code\n```\n# B\n",
+			want:  []string{"# A\n```\n# code\n~~~\n# code\n```\n", "# B\n"},
+		},
+		{
+			name:  "indented code block",
+			input: "# A\n\n    # not a header\n\n# B\n",
+			want:  []string{"# A\n\n    # not a header\n\n", "# B\n"},
+		},
+		{
+			name:  "html comment block",
+			input: "# A\n<!--\n# hidden\n-->\n# B\n",
+			want:  []string{"# A\n<!--\n# hidden\n-->\n", "# B\n"},
+		},
+		{
+			name:  "blockquote",
+			input: "# A\n> # quoted\n# B\n",
+			want:  []string{"# A\n> # quoted\n", "# B\n"},
+		},
+		{
+			name:  "indented header up to three spaces",
+			input: "# A\ntext\n   # B\n",
+			want:  []string{"# A\ntext\n", "   # B\n"},
+		},
+		{
+			name:  "hash without space is not a header",
+			input: "# A\n#hashtag\n",
+			want:  []string{"# A\n#hashtag\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MarkdownLevels: []int{1, 2, 3, 4, 5, 6}}
+
+			var got []string
+			for _, chunk := range chopMarkdown(t, cfg, tt.input) {
+				got = append(got, chunk.Text)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarkdownSplitSectionsKeepsCodeBlocks(t *testing.T) {
+	code := "```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n"
+	input := "# Example\nSome words before the code.\n" + code + "Some words after the code.\n"
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1},
+		ChunkSize:      60,
+		SplitSections:  true,
+	}
+
+	var got []string
+	for _, chunk := range chopMarkdown(t, cfg, input) {
+		got = append(got, chunk.Text)
+	}
+
+	assert.Equal(t, []string{
+		"# Example\nSome words before the code.\n",
+		code,
+		"Some words after the code.\n",
+	}, got)
+}
+
+func TestMarkdownSplitSectionsLongCodeBlock(t *testing.T) {
+	code := "```\n" + strings.Repeat("line of code\n", 10) + "```\n"
+
+	cfg := &config.Config{
+		MarkdownLevels: []int{1},
+		ChunkSize:      50,
+		SplitSections:  true,
+	}
+
+	chunks := chopMarkdown(t, cfg, "# Code\n"+code)
+	require.Greater(t, len(chunks), 2)
+
+	var text strings.Builder
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Text), cfg.ChunkSize)
+		text.WriteString(chunk.Text)
+	}
+	assert.Equal(t, "# Code\n"+code, text.String())
+}
+
+func TestMarkdownHeaderForms(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		strip bool
+		want  []Chunk
+	}{
+		{
+			name:  "setext headers",
+			input: "Title\n=====\nintro\n\nSection\n-------\ntext\n",
+			want: []Chunk{
+				{Text: "Title\n=====\nintro\n\n", Metadata: headerMetadata("Title")},
+				{Text: "Section\n-------\ntext\n", Metadata: headerMetadata("Title", "Section")},
+			},
+		},
+		{
+			name:  "setext header after paragraph",
+			input: "# Doc\nsome text\n\nMulti line\ntitle\n---\nbody\n",
+			want: []Chunk{
+				{Text: "# Doc\nsome text\n\n", Metadata: headerMetadata("Doc")},
+				{Text: "Multi line\ntitle\n---\nbody\n", Metadata: headerMetadata("Doc", "Multi line title")},
+			},
+		},
+		{
+			name:  "setext headers stripped",
+			input: "Title\n=====\nintro\n",
+			strip: true,
+			want: []Chunk{
+				{Text: "intro\n", Metadata: headerMetadata("Title")},
+			},
+		},
+		{
+			name:  "thematic break is not a header",
+			input: "# A\ntext\n\n---\nmore\n",
+			want: []Chunk{
+				{Text: "# A\ntext\n\n---\nmore\n", Metadata: headerMetadata("A")},
+			},
+		},
+		{
+			name:  "closing hashes",
+			input: "# Title #\n## Sub ##   \n### C# ###\n#### C#\n",
+			want: []Chunk{
+				{Text: "# Title #\n", Metadata: headerMetadata("Title")},
+				{Text: "## Sub ##   \n", Metadata: headerMetadata("Title", "Sub")},
+				{Text: "### C# ###\n", Metadata: headerMetadata("Title", "Sub", "C#")},
+				{Text: "#### C#\n", Metadata: headerMetadata("Title", "Sub", "C#", "C#")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				MarkdownLevels: []int{1, 2, 3, 4, 5, 6},
+				AddMetadata:    true,
+				StripHeaders:   tt.strip,
+			}
+
+			assert.Equal(t, tt.want, chopMarkdown(t, cfg, tt.input))
+		})
+	}
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Chunk
+		wantErr string
+	}{
+		{
+			name:  "yaml",
+			input: "---\ntitle: Guide\ntags: [a, b]\nversion: 2\n---\n# Intro\ntext\n",
+			want: []Chunk{
+				{Text: "# Intro\ntext\n", Metadata: withMetadata(headerMetadata("Intro"), map[string]any{
+					"title": "Guide", "tags": []any{"a", "b"}, "version": 2,
+				})},
+			},
+		},
+		{
+			name:  "yaml closed with dots",
+			input: "---\ntitle: Guide\n...\ntext\n",
+			want: []Chunk{
+				{Text: "text\n", Metadata: map[string]any{"title": "Guide"}},
+			},
+		},
+		{
+			name:  "toml",
+			input: "+++\ntitle = \"Guide\"\ndraft = true\n+++\n# Intro\ntext\n",
+			want: []Chunk{
+				{Text: "# Intro\ntext\n", Metadata: withMetadata(headerMetadata("Intro"), map[string]any{"title": "Guide", "draft": true})},
+			},
+		},
+		{
+			name:  "unclosed front matter is content",
+			input: "---\ntext\n",
+			want: []Chunk{
+				{Text: "---\ntext\n", Metadata: map[string]any{}},
+			},
+		},
+		{
+			name:  "invalid yaml is content",
+			input: "---\ntitle: [\n---\ntext\n",
+			want: []Chunk{
+				{Text: "---\n", Metadata: map[string]any{}},
+				{Text: "title: [\n---\ntext\n", Metadata: map[string]any{"Header 2": "title: [", "breadcrumb": []string{"title: ["}, "h_path": "title: ["}},
+			},
+		},
+		{
+			name:  "thematic break is content",
+			input: "---\nSome intro.\n---\n\n# Title\nbody\n",
+			want: []Chunk{
+				{Text: "---\n", Metadata: map[string]any{}},
+				{Text: "Some intro.\n---\n\n", Metadata: map[string]any{"Header 2": "Some intro.", "breadcrumb": []string{"Some intro."}, "h_path": "Some intro."}},
+				{Text: "# Title\nbody\n", Metadata: headerMetadata("Title")},
+			},
+		},
+		{
+			name:  "yaml list is content",
+			input: "---\n- one\n- two\n...\ntext\n",
+			want: []Chunk{
+				{Text: "---\n- one\n- two\n...\ntext\n", Metadata: map[string]any{}},
+			},
+		},
+		{
+			name:    "invalid toml",
+			input:   "+++\ntitle = [\n+++\ntext\n",
+			wantErr: "failed to parse TOML front matter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MarkdownLevels: []int{1, 2}, AddMetadata: true}
+
+			var chunks []Chunk
+			sink := SinkFunc(func(chunk Chunk) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+
+			chopper, err := NewMarkdownChopper(cfg, strings.NewReader(tt.input), sink)
+			require.NoError(t, err)
+
+			err = chopper.Chop()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, chunks)
+		})
+	}
+}
+
+func TestMarkdownHeaderHierarchy(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		levels []int
+		want   []map[string]any
+	}{
+		{
+			name:   "new parent clears deeper levels",
+			input:  "# Intro\n## A\n### x\ntext\n## B\ntext\n",
+			levels: []int{1, 2, 3},
+			want: []map[string]any{
+				headerMetadata("Intro"),
+				headerMetadata("Intro", "A"),
+				headerMetadata("Intro", "A", "x"),
+				headerMetadata("Intro", "B"),
+			},
+		},
+		{
+			name:   "new top level header resets stack",
+			input:  "# One\n## Setup\n### Deep\ntext\n# Two\ntext\n",
+			levels: []int{1, 2, 3},
+			want: []map[string]any{
+				headerMetadata("One"),
+				headerMetadata("One", "Setup"),
+				headerMetadata("One", "Setup", "Deep"),
+				headerMetadata("Two"),
+			},
+		},
+		{
+			name:   "skipped levels",
+			input:  "# Intro\n### Setup\ntext\n## Usage\ntext\n",
+			levels: []int{1, 2, 3},
+			want: []map[string]any{
+				headerMetadata("Intro"),
+				{"Header 1": "Intro", "Header 3": "Setup", "breadcrumb": []string{"Intro", "Setup"}, "h_path": "Intro > Setup"},
+				headerMetadata("Intro", "Usage"),
+			},
+		},
+		{
+			name:   "headers outside split levels are ignored",
+			input:  "# Intro\n## Setup\n### Linux\ntext\n## Usage\ntext\n",
+			levels: []int{1, 2},
+			want: []map[string]any{
+				headerMetadata("Intro"),
+				headerMetadata("Intro", "Setup"),
+				headerMetadata("Intro", "Usage"),
+			},
+		},
+		{
+			name:   "text before first header",
+			input:  "preamble\n# Intro\ntext\n",
+			levels: []int{1},
+			want: []map[string]any{
+				{},
+				headerMetadata("Intro"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MarkdownLevels: tt.levels, AddMetadata: true}
+
+			var got []map[string]any
+			for _, chunk := range chopMarkdown(t, cfg, tt.input) {
+				got = append(got, chunk.Metadata)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarkdownMetadataIsCopiedPerChunk(t *testing.T) {
+	cfg := &config.Config{MarkdownLevels: []int{1, 2}, AddMetadata: true}
+
+	chunks := chopMarkdown(t, cfg, "---\ntitle: Guide\n---\n# A\n## a1\ntext\n## a2\ntext\n")
+	require.Len(t, chunks, 3)
+
+	chunks[0].Metadata["title"] = "changed"
+	chunks[1].Metadata["breadcrumb"].([]string)[0] = "changed"
+
+	assert.Equal(t, withMetadata(headerMetadata("A", "a2"), map[string]any{"title": "Guide"}), chunks[2].Metadata)
+}
+
+func randomMarkdown(rng *rand.Rand) string {
+	lines := []string{
+		"# Title", "## Section ##", "### Sub", "#### Deep", "text line", "another line of text",
+		"", "", "   ", "Setext", "=====", "-----", "```bash", "# comment in code", "```",
+		"~~~", "    indented code", "> # quote", "<div>", "</div>", "- item", "1. item",
+		"text with crlf\r", "#hashtag",
+	}
+
+	var doc strings.Builder
+	for range rng.IntN(40) {
+		doc.WriteString(lines[rng.IntN(len(lines))])
+		doc.WriteString("\n")
+	}
+	if rng.IntN(2) == 0 {
+		doc.WriteString("no trailing newline")
+	}
+
+	return doc.String()
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	example, err := os.ReadFile("../tests/example.md")
+	require.NoError(t, err)
+
+	inputs := []string{string(example), "", "\n\n", "\n\n# A\ntext\n\n\n", "text\r\n\r\n# A\r\n"}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		inputs = append(inputs, randomMarkdown(rng))
+	}
+
+	configs := map[string]config.Config{
+		"sections":        {MarkdownLevels: []int{1, 2, 3}},
+		"all levels":      {MarkdownLevels: []int{1, 2, 3, 4, 5, 6}, AddMetadata: true},
+		"split":           {MarkdownLevels: []int{1, 2}, ChunkSize: 30, SplitSections: true},
+		"merge":           {MarkdownLevels: []int{1, 2, 3}, ChunkSize: 100, MergeSections: true},
+		"split and merge": {MarkdownLevels: []int{2}, ChunkSize: 50, SplitSections: true, MergeSections: true},
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			for _, input := range inputs {
+				var text strings.Builder
+				for _, chunk := range chopMarkdown(t, &cfg, input) {
+					text.WriteString(chunk.Text)
+				}
+
+				if strings.TrimSpace(input) == "" {
+					assert.Empty(t, text.String())
+					continue
+				}
+				assert.Equal(t, input, text.String())
+			}
+		})
+	}
+}
+
+func TestMarkdownRoundTripFrontMatter(t *testing.T) {
+	frontMatter := "---\ntitle: Guide\n---\n"
+	body := "\n# Intro\n\ntext\n\n## Setup\n\n```sh\n# install\n```\n"
+
+	cfg := &config.Config{MarkdownLevels: []int{1, 2}, AddMetadata: true}
+
+	var text strings.Builder
+	for _, chunk := range chopMarkdown(t, cfg, frontMatter+body) {
+		text.WriteString(chunk.Text)
+	}
+
+	assert.Equal(t, body, text.String())
+}
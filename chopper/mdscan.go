@@ -0,0 +1,183 @@
+package chopper
+
+import (
+	"regexp"
+	"strings"
+)
+
+type lineKind int
+
+const (
+	textLine lineKind = iota
+	blankLine
+	headerLine
+	setextLine
+	fenceOpenLine
+	fenceCloseLine
+	codeLine
+	htmlLine
+	quoteLine
+	breakLine
+)
+
+var (
+	atxHeaderRgx = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t]*$`)
+	fenceRgx     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	setextRgx    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	breakRgx     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	closingRgx   = regexp.MustCompile(`(?:^|[ \t]+)#+$`)
+	quoteRgx     = regexp.MustCompile(`^ {0,3}>`)
+	htmlRawRgx   = regexp.MustCompile(`(?i)^ {0,3}<(script|pre|style|textarea)(?:[\s>]|$)`)
+	htmlBlockRgx = regexp.MustCompile(`^ {0,3}<(?:!--|\?|![A-Za-z]|!\[CDATA\[|/?[A-Za-z][A-Za-z0-9-]*(?:[\s/>]|$))`)
+)
+
+type mdLine struct {
+	kind  lineKind
+	level int
+	title string
+}
+
+// blockScanner classifies markdown lines following the CommonMark block
+// structure closely enough to know where headers can appear: never inside
+// fenced or indented code, HTML blocks or blockquotes.
+type blockScanner struct {
+	fence     string
+	htmlEnd   string
+	inHTML    bool
+	paragraph bool
+	// lines of the paragraph being scanned, a setext underline turns them
+	// into a header
+	text []string
+}
+
+func (b *blockScanner) scan(line string) mdLine {
+	info := b.classify(line)
+	if info.kind == textLine {
+		b.text = append(b.text, strings.TrimSpace(line))
+	} else {
+		b.text = nil
+	}
+	return info
+}
+
+func (b *blockScanner) classify(line string) mdLine {
+	blank := strings.TrimSpace(line) == ""
+
+	if b.fence != "" {
+		if isClosingFence(line, b.fence) {
+			b.fence = ""
+			return mdLine{kind: fenceCloseLine}
+		}
+		return mdLine{kind: codeLine}
+	}
+
+	if b.inHTML {
+		if b.htmlEnd == "" {
+			if blank {
+				b.inHTML = false
+				return mdLine{kind: blankLine}
+			}
+		} else if strings.Contains(strings.ToLower(line), b.htmlEnd) {
+			b.inHTML = false
+		}
+		return mdLine{kind: htmlLine}
+	}
+
+	if blank {
+		b.paragraph = false
+		return mdLine{kind: blankLine}
+	}
+
+	if !b.paragraph && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) {
+		return mdLine{kind: codeLine}
+	}
+
+	if len(b.text) > 0 {
+		if m := setextRgx.FindStringSubmatch(line); m != nil {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			b.paragraph = false
+			return mdLine{kind: setextLine, level: level, title: strings.Join(b.text, " ")}
+		}
+	}
+
+	// a thematic break, unless it underlines a paragraph above
+	if breakRgx.MatchString(line) {
+		b.paragraph = false
+		return mdLine{kind: breakLine}
+	}
+
+	if m := fenceRgx.FindStringSubmatch(line); m != nil && !(m[1][0] == '`' && strings.Contains(m[2], "`")) {
+		b.fence = m[1]
+		b.paragraph = false
+		return mdLine{kind: fenceOpenLine}
+	}
+
+	if m := atxHeaderRgx.FindStringSubmatch(line); m != nil {
+		b.paragraph = false
+		return mdLine{kind: headerLine, level: len(m[1]), title: m[2]}
+	}
+
+	if quoteRgx.MatchString(line) {
+		// following lines can be lazy continuations of the quote
+		b.paragraph = true
+		return mdLine{kind: quoteLine}
+	}
+
+	if htmlBlockRgx.MatchString(line) {
+		b.inHTML = true
+		b.htmlEnd = htmlBlockEnd(line)
+		if b.htmlEnd != "" && strings.Contains(strings.ToLower(line[strings.Index(line, "<")+1:]), b.htmlEnd) {
+			b.inHTML = false
+		}
+		b.paragraph = false
+		return mdLine{kind: htmlLine}
+	}
+
+	b.paragraph = true
+	return mdLine{kind: textLine}
+}
+
+// htmlBlockEnd returns the text closing the HTML block started by line, or
+// an empty string when the block ends with a blank line.
+func htmlBlockEnd(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	switch {
+	case strings.HasPrefix(trimmed, "<!--"):
+		return "-->"
+	case strings.HasPrefix(trimmed, "<?"):
+		return "?>"
+	case strings.HasPrefix(trimmed, "<![CDATA["):
+		return "]]>"
+	case strings.HasPrefix(trimmed, "<!"):
+		return ">"
+	}
+
+	if m := htmlRawRgx.FindStringSubmatch(line); m != nil {
+		return "</" + strings.ToLower(m[1]) + ">"
+	}
+
+	return ""
+}
+
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+
+	marker := strings.TrimLeft(trimmed, fence[:1])
+	if len(trimmed)-len(marker) < len(fence) {
+		return false
+	}
+
+	return strings.TrimSpace(marker) == ""
+}
+
+// trimClosingHashes removes the optional closing sequence of an ATX header,
+// e.g. "Title ##" becomes "Title".
+func trimClosingHashes(title string) string {
+	return closingRgx.ReplaceAllString(title, "")
+}
@@ -0,0 +1,48 @@
+package chopper
+
+import (
+	"maps"
+	"sort"
+	"strings"
+)
+
+// pageBreak separates pages as paragraphs, so choppers prefer to split there
+const pageBreak = "\n\n"
+
+// PagedReader reads the text of a paged document such as a PDF. Choppers
+// reading from it add page and page_range metadata to chunks.
+type PagedReader struct {
+	*strings.Reader
+	pages []span
+}
+
+func NewPagedReader(pages []string) *PagedReader {
+	var text strings.Builder
+	spans := make([]span, 0, len(pages))
+	for i, page := range pages {
+		if i > 0 {
+			text.WriteString(pageBreak)
+		}
+		start := text.Len()
+		text.WriteString(page)
+		spans = append(spans, span{start: start, end: text.Len()})
+	}
+
+	return &PagedReader{Reader: strings.NewReader(text.String()), pages: spans}
+}
+
+// pageMetadata adds the one based numbers of the first page and of the page
+// range of input bytes [start, end) to metadata.
+func pageMetadata(metadata map[string]any, pages []span, start, end int) map[string]any {
+	first := sort.Search(len(pages), func(i int) bool { return pages[i].end > start })
+	last := sort.Search(len(pages), func(i int) bool { return pages[i].start >= end }) - 1
+	first = min(first, len(pages)-1)
+	last = max(last, first)
+
+	paged := make(map[string]any, len(metadata)+2)
+	maps.Copy(paged, metadata)
+	paged["page"] = first + 1
+	paged["page_range"] = []int{first + 1, last + 1}
+
+	return paged
+}
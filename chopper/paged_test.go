@@ -0,0 +1,94 @@
+package chopper
+
+import (
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageMetadata(t *testing.T) {
+	// pages "one", "two" and "three" separated by blank lines
+	pages := NewPagedReader([]string{"one", "two", "three"}).pages
+
+	tests := []struct {
+		name       string
+		start, end int
+		page       int
+		pageRange  []int
+	}{
+		{name: "first page", start: 0, end: 3, page: 1, pageRange: []int{1, 1}},
+		{name: "last page", start: 10, end: 15, page: 3, pageRange: []int{3, 3}},
+		{name: "across pages", start: 1, end: 12, page: 1, pageRange: []int{1, 3}},
+		{name: "leading page break", start: 3, end: 8, page: 2, pageRange: []int{2, 2}},
+		{name: "trailing page break", start: 5, end: 10, page: 2, pageRange: []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := pageMetadata(map[string]any{"Header 1": "Title"}, pages, tt.start, tt.end)
+			assert.Equal(t, map[string]any{"Header 1": "Title", "page": tt.page, "page_range": tt.pageRange}, metadata)
+		})
+	}
+}
+
+func TestPagedInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   config.ChunkMethod
+		cfg      *config.Config
+		expected []Chunk
+	}{
+		{
+			name:   "word",
+			method: config.Word,
+			cfg:    &config.Config{ChunkSize: 3},
+			expected: []Chunk{
+				{Text: "first page text", Metadata: map[string]any{"page": 1, "page_range": []int{1, 1}}},
+				{Text: "second page text", Metadata: map[string]any{"page": 2, "page_range": []int{2, 2}}},
+			},
+		},
+		{
+			name:   "recursive",
+			method: config.Recursive,
+			cfg:    &config.Config{ChunkSize: 40},
+			expected: []Chunk{
+				{Text: "first page text\n\nsecond page text", Metadata: map[string]any{"page": 1, "page_range": []int{1, 2}}},
+			},
+		},
+		{
+			name:   "markdown",
+			method: config.Markdown,
+			cfg:    &config.Config{ChunkSize: 100, MarkdownLevels: []int{1}, AddMetadata: true},
+			expected: []Chunk{
+				{Text: "first page text\n\n", Metadata: map[string]any{"page": 1, "page_range": []int{1, 1}}},
+				{Text: "# second page text", Metadata: map[string]any{
+					"Header 1": "second page text", "breadcrumb": []string{"second page text"}, "h_path": "second page text",
+					"page": 2, "page_range": []int{2, 2},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := []string{"first page text", "second page text"}
+			if tt.method == config.Markdown {
+				pages[1] = "# " + pages[1]
+			}
+
+			var chunks []Chunk
+			sink := SinkFunc(func(chunk Chunk) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+
+			chopper, err := NewChopper(tt.method, tt.cfg, NewPagedReader(pages), sink)
+			require.NoError(t, err)
+			require.NoError(t, chopper.Chop())
+
+			assert.Equal(t, tt.expected, chunks)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package chopper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position locates a chunk in the original input, before cleaning. Offsets
+// are zero based with exclusive ends, lines are one based and inclusive.
+type Position struct {
+	StartByte int `json:"start_byte"`
+	EndByte   int `json:"end_byte"`
+	StartRune int `json:"start_rune"`
+	EndRune   int `json:"end_rune"`
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// positionTracker converts byte offsets to rune offsets and line numbers. It
+// records the input as it's read and drops it up to the start of the last
+// located chunk, so chunk starts must not decrease.
+type positionTracker struct {
+	reader io.Reader
+	buffer []byte
+	offset int
+	runes  int
+	lines  int
+}
+
+func (t *positionTracker) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p)
+	t.buffer = append(t.buffer, p[:n]...)
+	return n, err
+}
+
+func (t *positionTracker) locate(start, end int) (*Position, error) {
+	if start < t.offset || end < start || end-t.offset > len(t.buffer) {
+		return nil, fmt.Errorf("invalid chunk position %d-%d", start, end)
+	}
+
+	skipped := t.buffer[:start-t.offset]
+	t.runes += countRunes(skipped)
+	t.lines += bytes.Count(skipped, []byte("\n"))
+	t.buffer = t.buffer[start-t.offset:]
+	t.offset = start
+
+	text := t.buffer[:end-start]
+	position := &Position{
+		StartByte: start,
+		EndByte:   end,
+		StartRune: t.runes,
+		EndRune:   t.runes + countRunes(text),
+		StartLine: t.lines + 1,
+		EndLine:   t.lines + 1,
+	}
+	if len(text) > 0 {
+		position.EndLine += bytes.Count(text[:len(text)-1], []byte("\n"))
+	}
+
+	return position, nil
+}
+
+// replaceInvalid replaces every invalid UTF-8 byte of data with U+FFFD, as
+// decoding it into runes does, and maps offsets of the result to offsets of
+// data. Offsets inside a replacement map to the end of the replaced byte.
+func replaceInvalid(data []byte) (string, func(offset int) int) {
+	if utf8.Valid(data) {
+		return string(data), func(offset int) int { return offset }
+	}
+
+	var text strings.Builder
+	// offsets[i] is the input offset of byte i of text
+	offsets := make([]int, 0, len(data)+1)
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			text.WriteRune(r)
+			offsets = append(offsets, i, i+1, i+1)
+		} else {
+			text.Write(data[i : i+size])
+			for k := range size {
+				offsets = append(offsets, i+k)
+			}
+		}
+		i += size
+	}
+	offsets = append(offsets, len(data))
+
+	return text.String(), func(offset int) int { return offsets[offset] }
+}
+
+// countRunes counts rune starts, unlike utf8.RuneCount it gives the same
+// total when the input is split inside a rune.
+func countRunes(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if utf8.RuneStart(c) {
+			n++
+		}
+	}
+	return n
+}
+
+// trackSplit wraps split to record the input span of every token.
+func trackSplit(split bufio.SplitFunc, spans *[]span) bufio.SplitFunc {
+	offset := 0
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token != nil {
+			// tokens are subslices of data, so the capacity difference is
+			// the offset of the token in data
+			start := offset + cap(data) - cap(token)
+			*spans = append(*spans, span{start: start, end: start + len(token)})
+		}
+		offset += advance
+		return advance, token, err
+	}
+}
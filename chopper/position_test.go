@@ -0,0 +1,166 @@
+package chopper
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositions(t *testing.T) {
+	essay, err := os.ReadFile("../tests/pg_essay.txt")
+	require.NoError(t, err)
+	example, err := os.ReadFile("../tests/example.md")
+	require.NoError(t, err)
+
+	unicode := strings.Repeat("Héllo wörld — 日本語のテキスト. Ünïcode line two!\r\n\r\n# Header\nΑλφα βήτα γάμμα? ", 20)
+	inputs := []string{string(essay), string(example), unicode}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		// word and sentence chunks join their units with single spaces
+		spaces bool
+	}{
+		{name: "char", cfg: config.Config{Method: config.Char, ChunkSize: 100, Overlap: 20}},
+		{name: "word", cfg: config.Config{Method: config.Word, ChunkSize: 20, Overlap: 5}, spaces: true},
+		{name: "sentence", cfg: config.Config{Method: config.Sentence, ChunkSize: 3, Overlap: 1}, spaces: true},
+		{name: "sentence runes", cfg: config.Config{Method: config.Sentence, ChunkSize: 200, Overlap: 50, LengthUnit: config.Runes}, spaces: true},
+		{name: "recursive", cfg: config.Config{Method: config.Recursive, ChunkSize: 200, Overlap: 40}},
+		{name: "recursive no overlap", cfg: config.Config{Method: config.Recursive, ChunkSize: 60}},
+		{name: "token", cfg: config.Config{Method: config.Token, ChunkSize: 50, Overlap: 10, Tokenizer: "cl100k_base"}},
+		{name: "markdown", cfg: config.Config{Method: config.Markdown, MarkdownLevels: []int{1, 2, 3}, AddMetadata: true}},
+		{name: "markdown split", cfg: config.Config{Method: config.Markdown, MarkdownLevels: []int{1, 2}, ChunkSize: 200, Overlap: 40, SplitSections: true}},
+		{name: "markdown merge", cfg: config.Config{Method: config.Markdown, MarkdownLevels: []int{1, 2, 3}, ChunkSize: 500, MergeSections: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Positions = true
+			tt.cfg.CleaningMode = config.CleanNone
+
+			for _, input := range inputs {
+				var chunks []Chunk
+				sink := SinkFunc(func(chunk Chunk) error {
+					chunks = append(chunks, chunk)
+					return nil
+				})
+
+				chopper, err := NewChopper(tt.cfg.Method, &tt.cfg, strings.NewReader(input), sink)
+				require.NoError(t, err)
+				require.NoError(t, chopper.Chop())
+				require.NotEmpty(t, chunks)
+
+				for _, chunk := range chunks {
+					position := chunk.Position
+					require.NotNil(t, position)
+
+					text := input[position.StartByte:position.EndByte]
+					if tt.spaces {
+						assert.Equal(t, strings.Join(strings.Fields(chunk.Text), ""), strings.Join(strings.Fields(text), ""))
+					} else {
+						assert.Equal(t, chunk.Text, text)
+					}
+
+					// char chunks are cut at byte offsets, which may fall inside a rune
+					if utf8.ValidString(text) {
+						assert.Equal(t, utf8.RuneCountInString(input[:position.StartByte]), position.StartRune)
+						assert.Equal(t, utf8.RuneCountInString(input[:position.EndByte]), position.EndRune)
+					}
+					assert.Equal(t, strings.Count(input[:position.StartByte], "\n")+1, position.StartLine)
+					assert.Equal(t, strings.Count(input[:position.EndByte-1], "\n")+1, position.EndLine)
+				}
+			}
+		})
+	}
+}
+
+func TestPositionsInvalidUTF8(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("\xff", 10),
+		"hello\xff\xfe world",
+		"caf\xc3 na\xefve r\xc3\xa9sum\xe9 \xe6\x97\xa5\xe6\x9c",
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{name: "char", cfg: config.Config{Method: config.Char, ChunkSize: 4}},
+		{name: "char overlap", cfg: config.Config{Method: config.Char, ChunkSize: 5, Overlap: 2}},
+		{name: "token", cfg: config.Config{Method: config.Token, ChunkSize: 3, Overlap: 1, Tokenizer: "cl100k_base"}},
+		{name: "recursive", cfg: config.Config{Method: config.Recursive, ChunkSize: 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Positions = true
+
+			for _, input := range inputs {
+				var chunks []Chunk
+				sink := SinkFunc(func(chunk Chunk) error {
+					chunks = append(chunks, chunk)
+					return nil
+				})
+
+				chopper, err := NewChopper(tt.cfg.Method, &tt.cfg, strings.NewReader(input), sink)
+				require.NoError(t, err)
+				require.NoError(t, chopper.Chop())
+				require.NotEmpty(t, chunks)
+
+				end := 0
+				for _, chunk := range chunks {
+					position := chunk.Position
+					require.NotNil(t, position)
+					assert.LessOrEqual(t, position.StartByte, position.EndByte)
+					assert.LessOrEqual(t, position.EndByte, len(input))
+					end = max(end, position.EndByte)
+
+					// invalid bytes are replaced in chunks, unless a chunk
+					// starts inside a replacement
+					if utf8.ValidString(chunk.Text) {
+						assert.Equal(t, chunk.Text, string([]rune(input[position.StartByte:position.EndByte])))
+					}
+				}
+				assert.Equal(t, len(input), end)
+			}
+		})
+	}
+}
+
+func TestPositionsDisabled(t *testing.T) {
+	var chunks []Chunk
+	sink := SinkFunc(func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+
+	cfg := &config.Config{ChunkSize: 5}
+	require.NoError(t, NewCharChopper(cfg, strings.NewReader("some text"), sink).Chop())
+
+	for _, chunk := range chunks {
+		assert.Nil(t, chunk.Position)
+	}
+}
+
+func TestPositionLines(t *testing.T) {
+	var chunks []Chunk
+	sink := SinkFunc(func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+
+	cfg := &config.Config{MarkdownLevels: []int{1}, Positions: true}
+	chopper, err := NewMarkdownChopper(cfg, strings.NewReader("---\ntitle: x\n---\n# A\ntext\n\n# B\nmore\n"), sink)
+	require.NoError(t, err)
+	require.NoError(t, chopper.Chop())
+
+	assert.Equal(t, []*Position{
+		{StartByte: 17, EndByte: 27, StartRune: 17, EndRune: 27, StartLine: 4, EndLine: 6},
+		{StartByte: 27, EndByte: 36, StartRune: 27, EndRune: 36, StartLine: 7, EndLine: 8},
+	}, []*Position{chunks[0].Position, chunks[1].Position})
+}
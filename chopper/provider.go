@@ -1,33 +1,49 @@
 package chopper
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 
 	"github.com/mirpo/chopdoc/config"
 )
 
 type Chunk struct {
-	Text     string            `json:"chunk"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+	ID        string         `json:"id,omitempty"`
+	Source    string         `json:"source,omitempty"`
+	Text      string         `json:"chunk"`
+	SHA256    string         `json:"sha256,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Position  *Position      `json:"position,omitempty"`
+	Embedding []float32      `json:"embedding,omitempty"`
 }
 
 type ChopperProvider interface {
 	Chop() error
 }
 
-func NewChopper(chunkMethod config.ChunkMethod, cfg *config.Config, rw *bufio.ReadWriter) (ChopperProvider, error) {
+func NewChopper(chunkMethod config.ChunkMethod, cfg *config.Config, r io.Reader, sink ChunkSink) (ChopperProvider, error) {
 	switch chunkMethod {
 	case config.Char:
-		return NewCharChopper(cfg, rw), nil
+		return NewCharChopper(cfg, r, sink), nil
 	case config.Word:
-		return NewWordChopper(cfg, rw), nil
+		return NewWordChopper(cfg, r, sink), nil
 	case config.Sentence:
-		return NewSentenceChopper(cfg, rw), nil
+		return NewSentenceChopper(cfg, r, sink)
 	case config.Recursive:
-		return NewRecursiveChopper(cfg, rw), nil
+		return NewRecursiveChopper(cfg, r, sink)
 	case config.Markdown:
-		return NewMarkdownChopper(cfg, rw), nil
+		return NewMarkdownChopper(cfg, r, sink)
+	case config.Token:
+		return NewTokenChopper(cfg, r, sink)
+	case config.HTML:
+		return NewHTMLChopper(cfg, r, sink)
+	case config.Table:
+		return NewTableChopper(cfg, r, sink), nil
+	case config.Auto:
+		// the method is picked by the extension of the input file
+		resolved := *cfg
+		resolved.Method, resolved.Language = cfg.ResolveMethod(cfg.InputFile)
+		return NewChopper(resolved.Method, &resolved, r, sink)
 	}
 	return nil, fmt.Errorf("unsupported chunkMethod: %s", chunkMethod)
 }
@@ -1,7 +1,6 @@
 package chopper
 
 import (
-	"bufio"
 	"strings"
 	"testing"
 
@@ -49,6 +48,55 @@ func TestNewChopper(t *testing.T) {
 			cfg:        &config.Config{ChunkSize: 100, MarkdownLevels: []int{1, 2, 3}},
 			expectType: "*chopper.MarkdownChopper",
 		},
+		{
+			name:       "token chopper",
+			method:     config.Token,
+			cfg:        &config.Config{ChunkSize: 100, Tokenizer: "cl100k_base"},
+			expectType: "*chopper.TokenChopper",
+		},
+		{
+			name:       "html chopper",
+			method:     config.HTML,
+			cfg:        &config.Config{ChunkSize: 100, MarkdownLevels: []int{1, 2, 3}},
+			expectType: "*chopper.HTMLChopper",
+		},
+		{
+			name:       "table chopper",
+			method:     config.Table,
+			cfg:        &config.Config{ChunkSize: 10},
+			expectType: "*chopper.TableChopper",
+		},
+		{
+			name:       "auto method for html file",
+			method:     config.Auto,
+			cfg:        &config.Config{InputFile: "site/index.html", ChunkSize: 100, MarkdownLevels: []int{1, 2}},
+			expectType: "*chopper.HTMLChopper",
+		},
+		{
+			name:       "auto method for markdown file",
+			method:     config.Auto,
+			cfg:        &config.Config{InputFile: "docs/README.md", ChunkSize: 100, MarkdownLevels: []int{1, 2}},
+			expectType: "*chopper.MarkdownChopper",
+		},
+		{
+			name:       "auto method for source file",
+			method:     config.Auto,
+			cfg:        &config.Config{InputFile: "main.go", ChunkSize: 100},
+			expectType: "*chopper.RecursiveChopper",
+		},
+		{
+			name:       "auto method without input file",
+			method:     config.Auto,
+			cfg:        &config.Config{ChunkSize: 100},
+			expectType: "*chopper.RecursiveChopper",
+		},
+		{
+			name:           "unknown tokenizer",
+			method:         config.Token,
+			cfg:            &config.Config{ChunkSize: 100, Tokenizer: "unknown"},
+			expectError:    true,
+			expectedErrMsg: "failed to load tokenizer unknown: Unknown encoding: unknown",
+		},
 		{
 			name:           "invalid method",
 			method:         config.ChunkMethod("invalid"),
@@ -69,9 +117,8 @@ func TestNewChopper(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			input := strings.NewReader("test input")
 			var output strings.Builder
-			rw := bufio.NewReadWriter(bufio.NewReader(input), bufio.NewWriter(&output))
 
-			chopper, err := NewChopper(tt.method, tt.cfg, rw)
+			chopper, err := NewChopper(tt.method, tt.cfg, input, NewJSONLSink(&output))
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -95,6 +142,8 @@ func TestNewChopper(t *testing.T) {
 						assert.IsType(t, &RecursiveChopper{}, chopper)
 					case config.Markdown:
 						assert.IsType(t, &MarkdownChopper{}, chopper)
+					case config.Token:
+						assert.IsType(t, &TokenChopper{}, chopper)
 					}
 				}
 			}
@@ -151,6 +200,12 @@ func TestChopperEdgeCases(t *testing.T) {
 			input:  "a",
 			cfg:    &config.Config{ChunkSize: 10, Overlap: 0},
 		},
+		{
+			name:   "unicode characters - token",
+			method: config.Token,
+			input:  "Hello 世界 🌍",
+			cfg:    &config.Config{ChunkSize: 2, Overlap: 1, Tokenizer: "o200k_base"},
+		},
 		{
 			name:   "exact chunk size - word",
 			method: config.Word,
@@ -163,18 +218,14 @@ func TestChopperEdgeCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			input := strings.NewReader(tt.input)
 			var output strings.Builder
-			rw := bufio.NewReadWriter(bufio.NewReader(input), bufio.NewWriter(&output))
 
-			chopper, err := NewChopper(tt.method, tt.cfg, rw)
+			chopper, err := NewChopper(tt.method, tt.cfg, input, NewJSONLSink(&output))
 			require.NoError(t, err)
 			require.NotNil(t, chopper)
 
 			err = chopper.Chop()
 			assert.NoError(t, err)
 
-			err = rw.Flush()
-			assert.NoError(t, err)
-
 			outputStr := output.String()
 			if outputStr != "" {
 				lines := strings.Split(strings.TrimSpace(outputStr), "\n")
@@ -1,83 +1,54 @@
 package chopper
 
 import (
-	"bufio"
-	"encoding/json"
-	"strings"
+	"io"
 
 	"github.com/mirpo/chopdoc/config"
 )
 
-var defaultSeparators = []string{"\n\n", "\n", " ", ".", ",", ""}
-
 type RecursiveChopper struct {
 	BaseChopper
-	buffer strings.Builder
+	reader   io.Reader
+	splitter *textSplitter
 }
 
-func NewRecursiveChopper(cfg *config.Config, rw *bufio.ReadWriter) *RecursiveChopper {
-	scanner := bufio.NewScanner(rw.Reader)
-	scanner.Split(bufio.ScanLines)
-
-	return &RecursiveChopper{
-		BaseChopper: BaseChopper{
-			cfg:     cfg,
-			encoder: json.NewEncoder(rw.Writer),
-			scanner: scanner,
-		},
+func NewRecursiveChopper(cfg *config.Config, r io.Reader, sink ChunkSink) (*RecursiveChopper, error) {
+	length, err := NewLengthFunc(cfg.LengthUnit, cfg.Tokenizer)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (r *RecursiveChopper) scanInput() error {
-	for r.scanner.Scan() {
-		line := r.scanner.Text()
-		r.buffer.WriteString(line + "\n")
-
-		if r.buffer.Len() >= r.cfg.ChunkSize {
-			if err := r.processBuffer(); err != nil {
-				return err
-			}
-		}
+	splitter, err := newTextSplitter(cfg, length)
+	if err != nil {
+		return nil, err
 	}
 
-	if r.buffer.Len() > 0 {
-		return r.processBuffer()
+	c := &RecursiveChopper{
+		BaseChopper: BaseChopper{
+			cfg:    cfg,
+			sink:   sink,
+			length: length,
+		},
+		splitter: splitter,
 	}
+	c.reader = c.trackPositions(r)
 
-	return r.scanner.Err()
+	return c, nil
 }
 
-func (r *RecursiveChopper) processBuffer() error {
-	text := r.buffer.String()
-	r.buffer.Reset()
-
-	for len(text) > 0 {
-		chunk, remaining, ok := r.splitText(text)
-		if !ok {
-			chunk = text[:r.cfg.ChunkSize]
-			remaining = text[r.cfg.ChunkSize:]
-		}
-		if err := r.writeChunk(chunk); err != nil {
-			return err
-		}
-		text = remaining
-	}
-	return nil
-}
-
-func (r *RecursiveChopper) splitText(text string) (chunk string, remaining string, ok bool) {
-	if len(text) <= r.cfg.ChunkSize {
-		return text, "", true
+func (r *RecursiveChopper) scanInput() error {
+	data, err := io.ReadAll(r.reader)
+	if err != nil {
+		return err
 	}
 
-	for _, sep := range defaultSeparators {
-		piece := string([]rune(text)[:r.cfg.ChunkSize])
-		if pos := strings.LastIndex(piece, sep); pos != -1 {
-			return text[:pos+len(sep)], text[pos+len(sep):], true
+	for _, chunk := range r.splitter.split(string(data)) {
+		if err := r.writeChunk(chunk.text, chunk.start, chunk.end, nil); err != nil {
+			return err
 		}
 	}
 
-	return "", text, false
+	return nil
 }
 
 func (r *RecursiveChopper) Chop() error {
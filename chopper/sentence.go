@@ -3,7 +3,7 @@ package chopper
 import (
 	"bufio"
 	"bytes"
-	"encoding/json"
+	"io"
 	"regexp"
 	"strings"
 
@@ -14,19 +14,32 @@ var endPunctuation = regexp.MustCompile(`([.!?]+)(\s*)`)
 
 type SentenceChopper struct {
 	BaseChopper
+	// input spans of the scanned sentences
+	spans []span
 }
 
-func NewSentenceChopper(cfg *config.Config, rw *bufio.ReadWriter) *SentenceChopper {
-	scanner := bufio.NewScanner(rw.Reader)
-	scanner.Split(scanSentences)
+func NewSentenceChopper(cfg *config.Config, r io.Reader, sink ChunkSink) (*SentenceChopper, error) {
+	// without an explicit length unit size and overlap are counted in sentences
+	var length LengthFunc
+	if cfg.LengthUnit != "" {
+		var err error
+		if length, err = NewLengthFunc(cfg.LengthUnit, cfg.Tokenizer); err != nil {
+			return nil, err
+		}
+	}
 
-	return &SentenceChopper{
+	s := &SentenceChopper{
 		BaseChopper: BaseChopper{
-			cfg:     cfg,
-			encoder: json.NewEncoder(rw.Writer),
-			scanner: scanner,
+			cfg:    cfg,
+			sink:   sink,
+			length: length,
 		},
 	}
+
+	s.scanner = bufio.NewScanner(s.trackPositions(r))
+	s.scanner.Split(trackSplit(scanSentences, &s.spans))
+
+	return s, nil
 }
 
 func scanSentences(data []byte, atEOF bool) (advance int, token []byte, err error) {
@@ -49,14 +62,17 @@ func scanSentences(data []byte, atEOF bool) (advance int, token []byte, err erro
 }
 
 func (s *SentenceChopper) scanInput() error {
+	if s.length != nil {
+		return s.packSentences()
+	}
+
 	sentences := make([]string, 0, s.cfg.ChunkSize+s.cfg.Overlap)
 	for s.scanner.Scan() {
 		sentences = append(sentences, s.scanner.Text())
 
 		if len(sentences) >= s.cfg.ChunkSize {
-			chunk := strings.Join(sentences, " ")
-
-			if err := s.writeChunk(chunk); err != nil {
+			spans := s.spans[len(s.spans)-len(sentences):]
+			if err := s.writeSentences(sentences, spans); err != nil {
 				return err
 			}
 
@@ -65,12 +81,13 @@ func (s *SentenceChopper) scanInput() error {
 			} else {
 				sentences = nil
 			}
+			s.spans = s.spans[len(s.spans)-len(sentences):]
 		}
 	}
 
 	if len(sentences) > 0 {
-		chunk := strings.Join(sentences, " ")
-		if err := s.writeChunk(chunk); err != nil {
+		spans := s.spans[len(s.spans)-len(sentences):]
+		if err := s.writeSentences(sentences, spans); err != nil {
 			return err
 		}
 	}
@@ -78,6 +95,31 @@ func (s *SentenceChopper) scanInput() error {
 	return s.scanner.Err()
 }
 
+func (s *SentenceChopper) packSentences() error {
+	var sentences []string
+	for s.scanner.Scan() {
+		sentences = append(sentences, s.scanner.Text())
+	}
+	if err := s.scanner.Err(); err != nil {
+		return err
+	}
+
+	splitter := &textSplitter{chunkSize: s.cfg.ChunkSize, overlap: s.cfg.Overlap, length: s.length}
+	for _, sp := range splitter.merge(sentences, " ") {
+		if err := s.writeSentences(sentences[sp.start:sp.end], s.spans[sp.start:sp.end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSentences writes the sentences joined with spaces, the position spans
+// them in the input.
+func (s *SentenceChopper) writeSentences(sentences []string, spans []span) error {
+	return s.writeChunk(strings.Join(sentences, " "), spans[0].start, spans[len(spans)-1].end, nil)
+}
+
 func (s *SentenceChopper) Chop() error {
 	return s.scanInput()
 }
@@ -0,0 +1,45 @@
+package chopper
+
+var defaultSeparators = []string{"\n\n", "\n", " ", ".", ",", ""}
+
+// languageSeparators are separator presets for source code and markup,
+// ordered from the strongest to the weakest boundary like in LangChain's
+// RecursiveCharacterTextSplitter.from_language.
+var languageSeparators = map[string][]string{
+	"python": {
+		"\nclass ", "\ndef ", "\n\tdef ",
+		"\n\n", "\n", " ", "",
+	},
+	"go": {
+		"\nfunc ", "\nvar ", "\nconst ", "\ntype ",
+		"\nif ", "\nfor ", "\nswitch ", "\ncase ",
+		"\n\n", "\n", " ", "",
+	},
+	"js": {
+		"\nfunction ", "\nconst ", "\nlet ", "\nvar ", "\nclass ",
+		"\nif ", "\nfor ", "\nwhile ", "\nswitch ", "\ncase ", "\ndefault ",
+		"\n\n", "\n", " ", "",
+	},
+	"markdown": {
+		"\n# ", "\n## ", "\n### ", "\n#### ", "\n##### ", "\n###### ",
+		"```\n",
+		"\n***\n", "\n---\n", "\n___\n",
+		"\n\n", "\n", " ", "",
+	},
+	"latex": {
+		"\n\\chapter{", "\n\\section{", "\n\\subsection{", "\n\\subsubsection{",
+		"\n\\begin{enumerate}", "\n\\begin{itemize}", "\n\\begin{description}",
+		"\n\\begin{list}", "\n\\begin{quote}", "\n\\begin{quotation}",
+		"\n\\begin{verse}", "\n\\begin{verbatim}", "\n\\begin{align}",
+		"$$", "$",
+		" ", "",
+	},
+	"html": {
+		"<body", "<div", "<p", "<br", "<li",
+		"<h1", "<h2", "<h3", "<h4", "<h5", "<h6",
+		"<span", "<table", "<tr", "<td", "<th", "<ul", "<ol",
+		"<header", "<footer", "<nav",
+		"<head", "<style", "<script", "<meta", "<title",
+		"",
+	},
+}
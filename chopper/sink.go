@@ -0,0 +1,53 @@
+package chopper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ChunkSink receives chunks as they are produced by a chopper.
+type ChunkSink interface {
+	WriteChunk(chunk Chunk) error
+}
+
+// DocumentSink is a ChunkSink which is told where the chunks of every input
+// document start, also of documents without chunks.
+type DocumentSink interface {
+	ChunkSink
+	StartDocument(name string) error
+}
+
+// StartDocument starts the document name in sink, if it's a DocumentSink.
+func StartDocument(sink ChunkSink, name string) error {
+	if s, ok := sink.(DocumentSink); ok {
+		return s.StartDocument(name)
+	}
+	return nil
+}
+
+// SinkFunc adapts an ordinary function to the ChunkSink interface.
+type SinkFunc func(chunk Chunk) error
+
+func (f SinkFunc) WriteChunk(chunk Chunk) error {
+	return f(chunk)
+}
+
+// JSONLSink writes every chunk as a single JSON line.
+type JSONLSink struct {
+	encoder *json.Encoder
+}
+
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return &JSONLSink{encoder: encoder}
+}
+
+func (s *JSONLSink) WriteChunk(chunk Chunk) error {
+	if err := s.encoder.Encode(chunk); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package chopper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+var errStopped = errors.New("iteration stopped")
+
+// Split chunks r using the method configured in cfg and yields chunks as Go
+// values. Invalid chunking options are yielded as an error before reading r.
+// Iteration stops at the first error, which is yielded with an empty Chunk,
+// or when ctx is cancelled.
+func Split(ctx context.Context, r io.Reader, cfg *config.Config) iter.Seq2[Chunk, error] {
+	return func(yield func(Chunk, error) bool) {
+		if err := cfg.ValidateChunking(); err != nil {
+			yield(Chunk{}, err)
+			return
+		}
+
+		stopped := false
+		sink := SinkFunc(func(chunk Chunk) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !yield(chunk, nil) {
+				stopped = true
+				return errStopped
+			}
+			return nil
+		})
+
+		chopper, err := NewChopper(cfg.Method, cfg, ContextReader(ctx, r), sink)
+		if err != nil {
+			yield(Chunk{}, err)
+			return
+		}
+
+		if err := chopper.Chop(); err != nil && !stopped {
+			yield(Chunk{}, err)
+		}
+	}
+}
+
+// ContextReader returns a reader of r which fails with the error of ctx once
+// ctx is cancelled.
+func ContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return r.Read(p)
+	})
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,123 @@
+package chopper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Method = config.Word
+	cfg.ChunkSize = 2
+
+	var got []string
+	for chunk, err := range Split(context.Background(), strings.NewReader("one two three four five"), cfg) {
+		require.NoError(t, err)
+		got = append(got, chunk.Text)
+	}
+
+	assert.Equal(t, []string{"one two", "three four", "five"}, got)
+}
+
+func TestSplitBreak(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Method = config.Word
+	cfg.ChunkSize = 1
+
+	var got []string
+	for chunk, err := range Split(context.Background(), strings.NewReader("one two three"), cfg) {
+		require.NoError(t, err)
+		got = append(got, chunk.Text)
+		if len(got) == 2 {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"one", "two"}, got)
+}
+
+func TestSplitCancelled(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Method = config.Char
+	cfg.ChunkSize = 5
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var errs []error
+	for _, err := range Split(ctx, strings.NewReader("hello world"), cfg) {
+		errs = append(errs, err)
+	}
+
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], context.Canceled))
+}
+
+func TestSplitInvalidMethod(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Method = config.ChunkMethod("invalid")
+
+	var errs []error
+	for _, err := range Split(context.Background(), strings.NewReader("hello"), cfg) {
+		errs = append(errs, err)
+	}
+
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "unsupported chunkMethod: invalid")
+}
+
+func TestSplitInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr string
+	}{
+		{
+			name:    "overlap larger than size",
+			cfg:     config.Config{Method: config.Char, ChunkSize: 4, Overlap: 6},
+			wantErr: "overlap must be less than chunk size",
+		},
+		{
+			name:    "no size",
+			cfg:     config.Config{Method: config.Word},
+			wantErr: "chunk size must be greater than 0",
+		},
+		{
+			name:    "invalid separator regex",
+			cfg:     config.Config{Method: config.Recursive, ChunkSize: 10, Separators: []string{"("}, SeparatorRegex: true},
+			wantErr: "invalid separator regex '(': error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			for _, err := range Split(context.Background(), strings.NewReader("hello world"), &tt.cfg) {
+				errs = append(errs, err)
+			}
+
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], tt.wantErr)
+		})
+	}
+}
+
+func TestSinkFunc(t *testing.T) {
+	var got []Chunk
+	sink := SinkFunc(func(chunk Chunk) error {
+		got = append(got, chunk)
+		return nil
+	})
+
+	chopper, err := NewChopper(config.Sentence, &config.Config{ChunkSize: 1}, strings.NewReader("One. Two."), sink)
+	require.NoError(t, err)
+	require.NoError(t, chopper.Chop())
+
+	assert.Equal(t, []Chunk{{Text: "One."}, {Text: "Two."}}, got)
+}
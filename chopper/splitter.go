@@ -0,0 +1,243 @@
+package chopper
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+// textSplitter implements recursive character splitting in the same way as
+// LangChain's RecursiveCharacterTextSplitter: text is split on the first
+// separator found, small splits are merged back together and splits that are
+// still too long are split again with the remaining separators.
+type textSplitter struct {
+	separators []separator
+	keep       config.KeepSeparator
+	chunkSize  int
+	overlap    int
+	length     LengthFunc
+}
+
+// piece is a part of the split text together with its byte offsets.
+type piece struct {
+	text  string
+	start int
+	end   int
+}
+
+// span is a half-open range of merged splits or input offsets.
+type span struct {
+	start int
+	end   int
+}
+
+type separator struct {
+	text    string
+	pattern *regexp.Regexp
+}
+
+func newTextSplitter(cfg *config.Config, length LengthFunc) (*textSplitter, error) {
+	separators, isRegex := defaultSeparators, false
+	if len(cfg.Separators) > 0 {
+		separators, isRegex = cfg.Separators, cfg.SeparatorRegex
+	} else if cfg.Language != "" {
+		preset, ok := languageSeparators[cfg.Language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported language: %s", cfg.Language)
+		}
+		separators = preset
+	}
+
+	compiled, err := compileSeparators(separators, isRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &textSplitter{
+		separators: compiled,
+		keep:       cfg.KeepSeparator,
+		chunkSize:  cfg.ChunkSize,
+		overlap:    cfg.Overlap,
+		length:     length,
+	}, nil
+}
+
+func compileSeparators(separators []string, isRegex bool) ([]separator, error) {
+	compiled := make([]separator, len(separators))
+	for i, sep := range separators {
+		compiled[i].text = sep
+		if sep == "" {
+			continue
+		}
+
+		expr := sep
+		if !isRegex {
+			expr = regexp.QuoteMeta(sep)
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid separator regex %q: %w", sep, err)
+		}
+		compiled[i].pattern = pattern
+	}
+	return compiled, nil
+}
+
+func (s *textSplitter) split(text string) []piece {
+	return s.splitText(text, 0, s.separators)
+}
+
+func (s *textSplitter) splitText(text string, offset int, separators []separator) []piece {
+	sep := separators[len(separators)-1]
+	var next []separator
+	for i, candidate := range separators {
+		if candidate.pattern == nil {
+			sep = candidate
+			break
+		}
+		if candidate.pattern.MatchString(text) {
+			sep = candidate
+			next = separators[i+1:]
+			break
+		}
+	}
+
+	splits := sep.split(text, offset, s.keep)
+
+	var chunks, good []piece
+	for _, p := range splits {
+		if s.length(p.text) < s.chunkSize {
+			good = append(good, p)
+			continue
+		}
+
+		if len(good) > 0 {
+			chunks = append(chunks, s.mergePieces(text, offset, good)...)
+			good = nil
+		}
+
+		if len(next) == 0 {
+			chunks = append(chunks, p)
+		} else {
+			chunks = append(chunks, s.splitText(p.text, p.start, next)...)
+		}
+	}
+
+	if len(good) > 0 {
+		chunks = append(chunks, s.mergePieces(text, offset, good)...)
+	}
+
+	return chunks
+}
+
+// mergePieces merges consecutive pieces of text, the merged text is taken
+// from text so dropped separators between merged pieces are restored and
+// counted.
+func (s *textSplitter) mergePieces(text string, offset int, pieces []piece) []piece {
+	texts := make([]string, len(pieces))
+	for i, p := range pieces {
+		texts[i] = p.text
+	}
+
+	spans := s.mergeGaps(texts, func(i int) int {
+		gap := text[pieces[i-1].end-offset : pieces[i].start-offset]
+		if gap == "" {
+			return 0
+		}
+		return s.length(gap)
+	})
+	merged := make([]piece, len(spans))
+	for i, sp := range spans {
+		start, end := pieces[sp.start].start, pieces[sp.end-1].end
+		merged[i] = piece{text: text[start-offset : end-offset], start: start, end: end}
+	}
+	return merged
+}
+
+// merge groups splits joined with separator into spans not longer than
+// chunkSize.
+func (s *textSplitter) merge(splits []string, separator string) []span {
+	separatorLen := s.length(separator)
+	return s.mergeGaps(splits, func(int) int { return separatorLen })
+}
+
+// mergeGaps groups splits into spans not longer than chunkSize, gap returns
+// the length of what joins split i to the split before it. Trailing splits of
+// a span, up to overlap in total, are carried over to the beginning of the
+// next span.
+func (s *textSplitter) mergeGaps(splits []string, gap func(i int) int) []span {
+	var spans []span
+	start, end, total := 0, 0, 0
+	for i, text := range splits {
+		textLen := s.length(text)
+		if end > start && total+textLen+gap(i) > s.chunkSize {
+			spans = append(spans, span{start: start, end: end})
+
+			for total > s.overlap || (total > 0 && total+textLen+gap(i) > s.chunkSize) {
+				total -= s.length(splits[start])
+				if end-start > 1 {
+					total -= gap(start + 1)
+				}
+				start++
+			}
+		}
+
+		if end > start {
+			total += gap(i)
+		}
+		end = i + 1
+		total += textLen
+	}
+
+	if end > start {
+		spans = append(spans, span{start: start, end: end})
+	}
+
+	return spans
+}
+
+// split splits text on the separator, keeping matched separators at the start
+// or the end of splits according to keep. An empty separator splits text into
+// runes.
+func (sep separator) split(text string, offset int, keep config.KeepSeparator) []piece {
+	var pieces []piece
+	add := func(start, end int) {
+		if end > start {
+			pieces = append(pieces, piece{text: text[start:end], start: offset + start, end: offset + end})
+		}
+	}
+
+	if sep.pattern == nil {
+		for i := 0; i < len(text); {
+			_, width := utf8.DecodeRuneInString(text[i:])
+			add(i, i+width)
+			i += width
+		}
+		return pieces
+	}
+
+	prev := 0
+	for _, m := range sep.pattern.FindAllStringIndex(text, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+
+		switch keep {
+		case config.KeepEnd:
+			add(prev, m[1])
+			prev = m[1]
+		case config.KeepNone:
+			add(prev, m[0])
+			prev = m[1]
+		default:
+			add(prev, m[0])
+			prev = m[0]
+		}
+	}
+	add(prev, len(text))
+
+	return pieces
+}
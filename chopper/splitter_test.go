@@ -0,0 +1,103 @@
+package chopper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecursiveSeparators(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		cfg        config.Config
+		wantChunks []string
+		wantErr    string
+	}{
+		{
+			name:       "custom separators keep start",
+			input:      "a;b;c|d;e",
+			cfg:        config.Config{ChunkSize: 4, Separators: []string{"|", ";"}},
+			wantChunks: []string{"a;b", ";c", "|d;e"},
+		},
+		{
+			name:       "keep end",
+			input:      "a;b;c|d;e",
+			cfg:        config.Config{ChunkSize: 4, Separators: []string{"|", ";"}, KeepSeparator: config.KeepEnd},
+			wantChunks: []string{"a;b;", "c|", "d;e"},
+		},
+		{
+			name:       "keep none restores separators inside chunks",
+			input:      "a;b;c|d;e",
+			cfg:        config.Config{ChunkSize: 4, Separators: []string{"|", ";"}, KeepSeparator: config.KeepNone},
+			wantChunks: []string{"a;b", "c", "d;e"},
+		},
+		{
+			name:       "keep none counts restored separators",
+			input:      "ab          cd ef",
+			cfg:        config.Config{ChunkSize: 6, Separators: []string{" "}, KeepSeparator: config.KeepNone},
+			wantChunks: []string{"ab", "cd ef"},
+		},
+		{
+			name:       "keep none counts repeated separators",
+			input:      "aa\n\n\n\n\n\n\n\nbb",
+			cfg:        config.Config{ChunkSize: 8, Separators: []string{"\n\n"}, KeepSeparator: config.KeepNone},
+			wantChunks: []string{"aa", "bb"},
+		},
+		{
+			name:       "regex separators",
+			input:      "one1two22three333four",
+			cfg:        config.Config{ChunkSize: 7, Separators: []string{`\d+`}, SeparatorRegex: true, KeepSeparator: config.KeepNone},
+			wantChunks: []string{"one1two", "three", "four"},
+		},
+		{
+			name:       "literal separators are not regex",
+			input:      "a.b.c",
+			cfg:        config.Config{ChunkSize: 2, Separators: []string{"."}},
+			wantChunks: []string{"a", ".b", ".c"},
+		},
+		{
+			name:  "python preset",
+			input: "import os\n\ndef a():\n    return 1\n\ndef b():\n    return 2\n",
+			cfg:   config.Config{ChunkSize: 30, Language: "python"},
+			wantChunks: []string{
+				"import os\n",
+				"\ndef a():\n    return 1\n",
+				"\ndef b():\n    return 2\n",
+			},
+		},
+		{
+			name:    "unknown language",
+			cfg:     config.Config{ChunkSize: 30, Language: "cobol"},
+			wantErr: "unsupported language: cobol",
+		},
+		{
+			name:    "invalid regex",
+			cfg:     config.Config{ChunkSize: 30, Separators: []string{"("}, SeparatorRegex: true},
+			wantErr: "invalid separator regex \"(\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			sink := SinkFunc(func(chunk Chunk) error {
+				got = append(got, chunk.Text)
+				return nil
+			})
+
+			chopper, err := NewRecursiveChopper(&tt.cfg, strings.NewReader(tt.input), sink)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, chopper.Chop())
+			assert.Equal(t, tt.wantChunks, got)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package chopper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+// TableChopper chops CSV and TSV tables into chunks of ChunkSize rows, every
+// chunk starts with the header row of the table.
+type TableChopper struct {
+	BaseChopper
+	reader *csv.Reader
+}
+
+func NewTableChopper(cfg *config.Config, r io.Reader, sink ChunkSink) *TableChopper {
+	t := &TableChopper{
+		BaseChopper: BaseChopper{
+			cfg:  cfg,
+			sink: sink,
+		},
+	}
+
+	t.reader = csv.NewReader(t.trackPositions(r))
+	t.reader.Comma = cfg.TableComma(cfg.InputFile)
+	t.reader.FieldsPerRecord = -1
+
+	return t
+}
+
+// ReadTableHeader reads the header row of a table, without the byte order
+// mark spreadsheet exports often start with.
+func ReadTableHeader(reader *csv.Reader) ([]string, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	return header, nil
+}
+
+func (t *TableChopper) Chop() error {
+	header, err := ReadTableHeader(t.reader)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read table: %w", err)
+	}
+
+	rows := make([][]string, 0, t.cfg.ChunkSize)
+	spans := make([]span, 0, t.cfg.ChunkSize)
+	written := false
+	for {
+		start := int(t.reader.InputOffset())
+		row, err := t.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read table: %w", err)
+		}
+
+		rows = append(rows, row)
+		spans = append(spans, span{start: start, end: int(t.reader.InputOffset())})
+
+		if len(rows) >= t.cfg.ChunkSize {
+			if err := t.writeRows(header, rows, spans); err != nil {
+				return err
+			}
+			written = true
+
+			keep := min(t.cfg.Overlap, len(rows))
+			rows = append(rows[:0], rows[len(rows)-keep:]...)
+			spans = append(spans[:0], spans[len(spans)-keep:]...)
+		}
+	}
+
+	// rows kept as overlap were written already
+	if len(rows) > 0 && (!written || len(rows) > t.cfg.Overlap) {
+		return t.writeRows(header, rows, spans)
+	}
+
+	return nil
+}
+
+// writeRows writes the header and rows as a table, the position spans the
+// rows in the input.
+func (t *TableChopper) writeRows(header []string, rows [][]string, spans []span) error {
+	var text strings.Builder
+	writer := csv.NewWriter(&text)
+	writer.Comma = t.reader.Comma
+	if err := writer.WriteAll(append([][]string{header}, rows...)); err != nil {
+		return fmt.Errorf("failed to write table chunk: %w", err)
+	}
+
+	return t.writeChunk(text.String(), spans[0].start, spans[len(spans)-1].end, nil)
+}
@@ -0,0 +1,85 @@
+package chopper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableChopper(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		file     string
+		size     int
+		overlap  int
+		expected []string
+	}{
+		{
+			name:     "rows per chunk",
+			input:    "\ufeffid,question\n1,How?\n2,\"Why, really?\"\n3,When?\n",
+			size:     2,
+			expected: []string{"id,question\n1,How?\n2,\"Why, really?\"\n", "id,question\n3,When?\n"},
+		},
+		{
+			name:     "overlap",
+			input:    "id,question\n1,How?\n2,Why?\n3,When?\n",
+			size:     2,
+			overlap:  1,
+			expected: []string{"id,question\n1,How?\n2,Why?\n", "id,question\n2,Why?\n3,When?\n"},
+		},
+		{
+			name:     "overlap only remainder",
+			input:    "id,question\n1,How?\n2,Why?\n",
+			size:     2,
+			overlap:  1,
+			expected: []string{"id,question\n1,How?\n2,Why?\n"},
+		},
+		{
+			name:     "tsv",
+			input:    "id\tquestion\n1\tHow?\n",
+			file:     "faq.tsv",
+			size:     10,
+			expected: []string{"id\tquestion\n1\tHow?\n"},
+		},
+		{
+			name:  "header only",
+			input: "id,question\n",
+			size:  10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{InputFile: tt.file, ChunkSize: tt.size, Overlap: tt.overlap}
+
+			var chunks []string
+			sink := SinkFunc(func(chunk Chunk) error {
+				chunks = append(chunks, chunk.Text)
+				return nil
+			})
+
+			require.NoError(t, NewTableChopper(cfg, strings.NewReader(tt.input), sink).Chop())
+			assert.Equal(t, tt.expected, chunks)
+		})
+	}
+}
+
+func TestTableChopperPositions(t *testing.T) {
+	cfg := &config.Config{ChunkSize: 1, Positions: true}
+
+	var positions []Position
+	sink := SinkFunc(func(chunk Chunk) error {
+		positions = append(positions, *chunk.Position)
+		return nil
+	})
+
+	require.NoError(t, NewTableChopper(cfg, strings.NewReader("id,question\n1,How?\n2,Why?\n"), sink).Chop())
+	assert.Equal(t, []Position{
+		{StartByte: 12, EndByte: 19, StartRune: 12, EndRune: 19, StartLine: 2, EndLine: 2},
+		{StartByte: 19, EndByte: 26, StartRune: 19, EndRune: 26, StartLine: 3, EndLine: 3},
+	}, positions)
+}
@@ -0,0 +1,87 @@
+package chopper
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/mirpo/chopdoc/tokenizer"
+)
+
+type TokenChopper struct {
+	BaseChopper
+	reader    io.Reader
+	tokenizer *tokenizer.Tokenizer
+}
+
+func NewTokenChopper(cfg *config.Config, r io.Reader, sink ChunkSink) (*TokenChopper, error) {
+	tok, err := tokenizer.Get(cfg.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TokenChopper{
+		BaseChopper: BaseChopper{
+			cfg:  cfg,
+			sink: sink,
+		},
+		tokenizer: tok,
+	}
+	t.reader = t.trackPositions(r)
+
+	return t, nil
+}
+
+func (t *TokenChopper) scanInput() error {
+	data, err := io.ReadAll(t.reader)
+	if err != nil {
+		return err
+	}
+
+	// the tokenizer replaces invalid UTF-8, offsets of the replaced text are
+	// mapped back to the input
+	text, inputOffset := replaceInvalid(data)
+	tokens := t.tokenizer.Encode(text)
+	firstBytes := make([]byte, len(tokens))
+	// offsets[i] is the input offset of token i
+	offsets := make([]int, len(tokens)+1)
+	for i, token := range tokens {
+		decoded := t.tokenizer.Decode([]int{token})
+		firstBytes[i] = decoded[0]
+		offsets[i+1] = offsets[i] + len(decoded)
+	}
+
+	// a single rune can be spread over several tokens, chunk boundaries are
+	// moved so that no rune is cut in half
+	runeStart := func(i int) bool {
+		return i >= len(tokens) || utf8.RuneStart(firstBytes[i])
+	}
+
+	start := 0
+	for start < len(tokens) {
+		end := min(start+t.cfg.ChunkSize, len(tokens))
+		for end > start+1 && !runeStart(end) {
+			end--
+		}
+
+		if err := t.writeChunk(t.tokenizer.Decode(tokens[start:end]), inputOffset(offsets[start]), inputOffset(offsets[end]), nil); err != nil {
+			return err
+		}
+
+		if end == len(tokens) {
+			break
+		}
+
+		next := max(end-t.cfg.Overlap, start+1)
+		for next < end && !runeStart(next) {
+			next++
+		}
+		start = next
+	}
+
+	return nil
+}
+
+func (t *TokenChopper) Chop() error {
+	return t.scanInput()
+}
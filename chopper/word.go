@@ -2,7 +2,7 @@ package chopper
 
 import (
 	"bufio"
-	"encoding/json"
+	"io"
 	"strings"
 
 	"github.com/mirpo/chopdoc/config"
@@ -10,19 +10,22 @@ import (
 
 type WordChopper struct {
 	BaseChopper
+	// input spans of the scanned words
+	spans []span
 }
 
-func NewWordChopper(cfg *config.Config, rw *bufio.ReadWriter) *WordChopper {
-	scanner := bufio.NewScanner(rw.Reader)
-	scanner.Split(bufio.ScanWords)
-
-	return &WordChopper{
+func NewWordChopper(cfg *config.Config, r io.Reader, sink ChunkSink) *WordChopper {
+	w := &WordChopper{
 		BaseChopper: BaseChopper{
-			cfg:     cfg,
-			encoder: json.NewEncoder(rw.Writer),
-			scanner: scanner,
+			cfg:  cfg,
+			sink: sink,
 		},
 	}
+
+	w.scanner = bufio.NewScanner(w.trackPositions(r))
+	w.scanner.Split(trackSplit(bufio.ScanWords, &w.spans))
+
+	return w
 }
 
 func (w *WordChopper) scanInput() error {
@@ -32,9 +35,7 @@ func (w *WordChopper) scanInput() error {
 		words = append(words, w.scanner.Text())
 
 		if len(words) >= w.cfg.ChunkSize {
-			chunk := strings.Join(words, " ")
-
-			if err := w.writeChunk(chunk); err != nil {
+			if err := w.writeWords(words); err != nil {
 				return err
 			}
 
@@ -43,12 +44,12 @@ func (w *WordChopper) scanInput() error {
 			} else {
 				words = nil
 			}
+			w.spans = w.spans[len(w.spans)-len(words):]
 		}
 	}
 
 	if len(words) > 0 {
-		chunk := strings.Join(words, " ")
-		if err := w.writeChunk(chunk); err != nil {
+		if err := w.writeWords(words); err != nil {
 			return err
 		}
 	}
@@ -56,6 +57,13 @@ func (w *WordChopper) scanInput() error {
 	return w.scanner.Err()
 }
 
+// writeWords writes the words joined with spaces, the position spans them in
+// the input.
+func (w *WordChopper) writeWords(words []string) error {
+	spans := w.spans[len(w.spans)-len(words):]
+	return w.writeChunk(strings.Join(words, " "), spans[0].start, spans[len(spans)-1].end, nil)
+}
+
 func (w *WordChopper) Chop() error {
 	return w.scanInput()
 }
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MethodChoice is the chunking method picked for a file extension by the auto
+// method, Language is the separator preset of the recursive method.
+type MethodChoice struct {
+	Method   ChunkMethod
+	Language string
+}
+
+// DefaultMethodMap maps file extensions to methods for the auto method, other
+// extensions use the recursive method.
+var DefaultMethodMap = map[string]MethodChoice{
+	".md":       {Method: Markdown},
+	".mdx":      {Method: Markdown},
+	".markdown": {Method: Markdown},
+	".py":       {Method: Recursive, Language: "python"},
+	".go":       {Method: Recursive, Language: "go"},
+	".js":       {Method: Recursive, Language: "js"},
+	".jsx":      {Method: Recursive, Language: "js"},
+	".mjs":      {Method: Recursive, Language: "js"},
+	".cjs":      {Method: Recursive, Language: "js"},
+	".ts":       {Method: Recursive, Language: "js"},
+	".tsx":      {Method: Recursive, Language: "js"},
+	".tex":      {Method: Recursive, Language: "latex"},
+	".html":     {Method: HTML},
+	".htm":      {Method: HTML},
+	".xhtml":    {Method: HTML},
+	".docx":     {Method: Markdown},
+	".odt":      {Method: Markdown},
+	".epub":     {Method: Markdown},
+	".csv":      {Method: Table},
+	".tsv":      {Method: Table},
+}
+
+// ParseMethodMap parses MethodMapList, a comma separated list of
+// extension=method entries where recursive can be followed by a language,
+// e.g. ".txt=sentence,.rst=recursive,.pyi=recursive:python".
+func (c *Config) ParseMethodMap() error {
+	list := strings.TrimSpace(c.MethodMapList)
+	if list == "" {
+		return nil
+	}
+
+	c.MethodMap = make(map[string]MethodChoice)
+	for _, entry := range strings.Split(list, ",") {
+		ext, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || ext == "" || value == "" {
+			return fmt.Errorf("invalid method map entry: '%s', expected format like '.txt=sentence'", entry)
+		}
+
+		method, language, _ := strings.Cut(value, ":")
+		choice := MethodChoice{Method: ChunkMethod(method), Language: language}
+
+		switch {
+		case !validMethods[choice.Method] || choice.Method == Auto:
+			return fmt.Errorf("invalid method in method map entry '%s': '%s'", entry, method)
+		case language != "" && (choice.Method != Recursive || !validLanguages[language]):
+			return fmt.Errorf("invalid language in method map entry '%s': '%s'", entry, language)
+		case choice.Method == Token && !validTokenizers[c.Tokenizer]:
+			return fmt.Errorf("invalid tokenizer: '%s'", c.Tokenizer)
+		}
+
+		c.MethodMap[normalizeExt(ext)] = choice
+	}
+
+	return nil
+}
+
+// ResolveMethod returns the method and separator language used for path by
+// the auto method. MethodMap overrides DefaultMethodMap, and explicit
+// separators or language take precedence over language presets.
+func (c *Config) ResolveMethod(path string) (ChunkMethod, string) {
+	ext := normalizeExt(filepath.Ext(path))
+
+	choice, ok := c.MethodMap[ext]
+	if !ok {
+		choice, ok = DefaultMethodMap[ext]
+	}
+	if !ok {
+		choice = MethodChoice{Method: Recursive}
+	}
+
+	language := choice.Language
+	if c.Language != "" || len(c.Separators) > 0 {
+		language = c.Language
+	}
+
+	return choice.Method, language
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
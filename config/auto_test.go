@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMethod(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		path         string
+		wantMethod   ChunkMethod
+		wantLanguage string
+	}{
+		{name: "markdown", path: "docs/guide.md", wantMethod: Markdown},
+		{name: "mdx", path: "page.MDX", wantMethod: Markdown},
+		{name: "python", path: "src/app.py", wantMethod: Recursive, wantLanguage: "python"},
+		{name: "typescript", path: "index.tsx", wantMethod: Recursive, wantLanguage: "js"},
+		{name: "html", path: "site/index.html", wantMethod: HTML},
+		{name: "csv", path: "data/faq.csv", wantMethod: Table},
+		{name: "docx", path: "letters/offer.docx", wantMethod: Markdown},
+		{name: "epub", path: "book.epub", wantMethod: Markdown},
+		{name: "unknown extension", path: "notes.txt", wantMethod: Recursive},
+		{name: "no extension", path: "LICENSE", wantMethod: Recursive},
+		{
+			name:       "overridden extension",
+			cfg:        Config{MethodMap: map[string]MethodChoice{".md": {Method: Sentence}}},
+			path:       "README.md",
+			wantMethod: Sentence,
+		},
+		{
+			name:         "added extension",
+			cfg:          Config{MethodMap: map[string]MethodChoice{".pyi": {Method: Recursive, Language: "python"}}},
+			path:         "types.pyi",
+			wantMethod:   Recursive,
+			wantLanguage: "python",
+		},
+		{
+			name:         "explicit language",
+			cfg:          Config{Language: "markdown"},
+			path:         "main.go",
+			wantMethod:   Recursive,
+			wantLanguage: "markdown",
+		},
+		{
+			name:       "explicit separators",
+			cfg:        Config{Separators: []string{"\n"}},
+			path:       "main.go",
+			wantMethod: Recursive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, language := tt.cfg.ResolveMethod(tt.path)
+			assert.Equal(t, tt.wantMethod, method)
+			assert.Equal(t, tt.wantLanguage, language)
+		})
+	}
+}
+
+func TestParseMethodMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    map[string]MethodChoice
+		wantErr string
+	}{
+		{
+			name: "empty",
+			list: "",
+		},
+		{
+			name: "methods and languages",
+			list: ".txt=sentence, rst=recursive,.PYI=recursive:python",
+			want: map[string]MethodChoice{
+				".txt": {Method: Sentence},
+				".rst": {Method: Recursive},
+				".pyi": {Method: Recursive, Language: "python"},
+			},
+		},
+		{
+			name:    "missing method",
+			list:    ".txt=",
+			wantErr: "invalid method map entry: '.txt=', expected format like '.txt=sentence'",
+		},
+		{
+			name:    "invalid method",
+			list:    ".txt=paragraph",
+			wantErr: "invalid method in method map entry '.txt=paragraph': 'paragraph'",
+		},
+		{
+			name:    "auto method",
+			list:    ".txt=auto",
+			wantErr: "invalid method in method map entry '.txt=auto': 'auto'",
+		},
+		{
+			name:    "language of other method",
+			list:    ".txt=markdown:python",
+			wantErr: "invalid language in method map entry '.txt=markdown:python': 'python'",
+		},
+		{
+			name:    "invalid language",
+			list:    ".rs=recursive:rust",
+			wantErr: "invalid language in method map entry '.rs=recursive:rust': 'rust'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MethodMapList: tt.list, Tokenizer: "cl100k_base"}
+			err := cfg.ParseMethodMap()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.MethodMap)
+		})
+	}
+}
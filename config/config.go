@@ -1,10 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 type ChunkMethod string
@@ -15,6 +18,111 @@ const (
 	Sentence  ChunkMethod = "sentence"
 	Recursive ChunkMethod = "recursive"
 	Markdown  ChunkMethod = "markdown"
+	Token     ChunkMethod = "token"
+	HTML      ChunkMethod = "html"
+	Table     ChunkMethod = "table"
+	Auto      ChunkMethod = "auto"
+)
+
+var validMethods = map[ChunkMethod]bool{
+	Char:      true,
+	Word:      true,
+	Sentence:  true,
+	Recursive: true,
+	Markdown:  true,
+	Token:     true,
+	HTML:      true,
+	Table:     true,
+	Auto:      true,
+}
+
+type LengthUnit string
+
+const (
+	Runes  LengthUnit = "runes"
+	Bytes  LengthUnit = "bytes"
+	Words  LengthUnit = "words"
+	Tokens LengthUnit = "tokens"
+)
+
+type KeepSeparator string
+
+const (
+	KeepStart KeepSeparator = "start"
+	KeepEnd   KeepSeparator = "end"
+	KeepNone  KeepSeparator = "none"
+)
+
+type IDScheme string
+
+const (
+	IDNone    IDScheme = "none"
+	IDUUIDv5  IDScheme = "uuidv5"
+	IDSHA256  IDScheme = "sha256"
+	IDOrdinal IDScheme = "ordinal"
+)
+
+var validLanguages = map[string]bool{
+	"python":   true,
+	"go":       true,
+	"js":       true,
+	"markdown": true,
+	"latex":    true,
+	"html":     true,
+}
+
+var validTokenizers = map[string]bool{
+	"cl100k_base": true,
+	"o200k_base":  true,
+	"p50k_base":   true,
+	"r50k_base":   true,
+}
+
+type InputFormat string
+
+const (
+	InputText  InputFormat = "text"
+	InputJSONL InputFormat = "jsonl"
+	InputCSV   InputFormat = "csv"
+	InputTSV   InputFormat = "tsv"
+)
+
+type OutputFormat string
+
+const (
+	FormatJSONL   OutputFormat = "jsonl"
+	FormatJSON    OutputFormat = "json"
+	FormatCSV     OutputFormat = "csv"
+	FormatParquet OutputFormat = "parquet"
+	FormatSQLite  OutputFormat = "sqlite"
+)
+
+var validOutputFormats = map[OutputFormat]bool{
+	FormatJSONL:   true,
+	FormatJSON:    true,
+	FormatCSV:     true,
+	FormatParquet: true,
+	FormatSQLite:  true,
+}
+
+var outputExtensions = map[string]OutputFormat{
+	".jsonl":   FormatJSONL,
+	".json":    FormatJSON,
+	".csv":     FormatCSV,
+	".parquet": FormatParquet,
+	".sqlite":  FormatSQLite,
+	".sqlite3": FormatSQLite,
+	".db":      FormatSQLite,
+}
+
+type Schema string
+
+const (
+	SchemaChopdoc    Schema = "chopdoc"
+	SchemaLangChain  Schema = "langchain"
+	SchemaLlamaIndex Schema = "llamaindex"
+	SchemaHaystack   Schema = "haystack"
+	SchemaCustom     Schema = "custom"
 )
 
 type CleaningMode string
@@ -28,7 +136,25 @@ const (
 
 type Config struct {
 	InputFile      string
+	Inputs         []string
+	Include        []string
+	Exclude        []string
+	GitIgnore      bool
 	OutputFile     string
+	OutputDir      string
+	OutputFormat   OutputFormat
+	Schema         Schema
+	RecordTemplate string
+	Embed          bool
+	EmbedURL       string
+	EmbedModel     string
+	EmbedAPIKey    string
+	EmbedBatchSize int
+	EmbedWorkers   int
+	EmbedRate      float64
+	EmbedRetries   int
+	Workers        int
+	Unordered      bool
 	Method         ChunkMethod
 	ChunkSize      int
 	Overlap        int
@@ -38,18 +164,49 @@ type Config struct {
 	MarkdownLevels []int
 	StripHeaders   bool
 	AddMetadata    bool
+	SplitSections  bool
+	MergeSections  bool
+	Tokenizer      string
+	LengthUnit     LengthUnit
+	SeparatorList  string
+	Separators     []string
+	SeparatorRegex bool
+	KeepSeparator  KeepSeparator
+	Language       string
+	MethodMapList  string
+	MethodMap      map[string]MethodChoice
+	Positions      bool
+	IDScheme       IDScheme
+	InputFormat    InputFormat
+	TextField      string
+	TextColumns    string
+	GroupBy        string
 }
 
 func NewConfig() *Config {
 	return &Config{
+		Method:         Char,
 		ChunkSize:      1000,
 		Overlap:        0,
 		CleaningMode:   CleanNone,
 		Piped:          false,
+		GitIgnore:      true,
+		Workers:        1,
 		MarkdownHeader: "1-6",
 		MarkdownLevels: []int{1, 2, 3, 4, 5, 6},
 		StripHeaders:   false,
 		AddMetadata:    false,
+		Tokenizer:      "cl100k_base",
+		KeepSeparator:  KeepStart,
+		IDScheme:       IDNone,
+		Schema:         SchemaChopdoc,
+		EmbedURL:       "https://api.openai.com/v1",
+		EmbedModel:     "text-embedding-3-small",
+		EmbedBatchSize: 64,
+		EmbedWorkers:   4,
+		EmbedRetries:   5,
+		InputFormat:    InputText,
+		TextField:      "text",
 	}
 }
 
@@ -76,42 +233,145 @@ func (c *Config) ParseMarkdownHeader() error {
 	return nil
 }
 
+// ParseSeparators parses SeparatorList given either as a JSON list or as a
+// comma separated list, where Go escape sequences like \n are interpreted.
+func (c *Config) ParseSeparators() error {
+	list := strings.TrimSpace(c.SeparatorList)
+	if list == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(list, "[") {
+		var separators []string
+		if err := json.Unmarshal([]byte(list), &separators); err != nil {
+			return fmt.Errorf("invalid separators list: %w", err)
+		}
+		c.Separators = separators
+		return nil
+	}
+
+	items := strings.Split(c.SeparatorList, ",")
+	c.Separators = make([]string, 0, len(items))
+	for _, item := range items {
+		if unquoted, err := strconv.Unquote(`"` + item + `"`); err == nil {
+			item = unquoted
+		}
+		c.Separators = append(c.Separators, item)
+	}
+
+	return nil
+}
+
+// TableComma returns the field delimiter of the table in the input at path,
+// TSV tables are tab separated.
+func (c *Config) TableComma(path string) rune {
+	if c.InputFormat == InputTSV || c.InputFormat != InputCSV && strings.EqualFold(filepath.Ext(path), ".tsv") {
+		return '\t'
+	}
+	return ','
+}
+
+// Warnings returns the options which are valid but have no effect with the
+// configured method.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.LengthUnit != "" && c.Method != Recursive && c.Method != Sentence && c.Method != Markdown && c.Method != HTML && c.Method != Auto {
+		warnings = append(warnings, "length unit is used only by recursive, sentence, markdown and html choppers, ignoring it")
+	}
+	return warnings
+}
+
 func (c *Config) Validate() error {
 	if !c.Piped {
-		if c.InputFile == "" {
+		if c.InputFile == "" && len(c.Inputs) == 0 {
 			return fmt.Errorf("input file is required")
 		}
 	}
 
-	if c.OutputFile != "" && filepath.Ext(c.OutputFile) != ".jsonl" {
-		return fmt.Errorf("output file must have .jsonl extension")
+	if c.OutputFormat == "" {
+		c.OutputFormat = FormatJSONL
+		if c.OutputFile != "" {
+			format, ok := outputExtensions[strings.ToLower(filepath.Ext(c.OutputFile))]
+			if !ok {
+				return fmt.Errorf("unknown output format of %s, expected .jsonl, .json, .csv, .parquet or .sqlite extension or format", c.OutputFile)
+			}
+			c.OutputFormat = format
+		}
+	}
+	if !validOutputFormats[c.OutputFormat] {
+		return fmt.Errorf("invalid output format: '%s'", c.OutputFormat)
+	}
+	if c.OutputFormat == FormatSQLite && c.OutputFile == "" && c.OutputDir == "" {
+		return fmt.Errorf("sqlite output requires an output file")
 	}
 
-	if c.ChunkSize <= 0 {
-		return fmt.Errorf("chunk size must be greater than 0")
+	switch c.Schema {
+	case "", SchemaChopdoc:
+	case SchemaLangChain, SchemaLlamaIndex, SchemaHaystack, SchemaCustom:
+		if c.OutputFormat != FormatJSONL && c.OutputFormat != FormatJSON {
+			return fmt.Errorf("schema %s is supported by jsonl and json output formats only", c.Schema)
+		}
+	default:
+		return fmt.Errorf("invalid schema: '%s'", c.Schema)
+	}
+	if (c.Schema == SchemaCustom) != (c.RecordTemplate != "") {
+		return fmt.Errorf("custom schema and template must be used together")
 	}
 
-	if c.Overlap >= c.ChunkSize {
-		return fmt.Errorf("overlap must be less than chunk size")
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative")
+	}
+
+	if c.OutputDir != "" {
+		if c.OutputFile != "" {
+			return fmt.Errorf("output and output-dir can't be used together")
+		}
+		if c.Piped {
+			return fmt.Errorf("output-dir requires input files")
+		}
 	}
 
-	validMethods := map[ChunkMethod]bool{
-		Char:      true,
-		Word:      true,
-		Sentence:  true,
-		Recursive: true,
-		Markdown:  true,
+	if err := c.ParseSeparators(); err != nil {
+		return err
 	}
+
+	if err := c.ValidateChunking(); err != nil {
+		return err
+	}
+
 	if !validMethods[c.Method] {
 		return fmt.Errorf("invalid chunking method: '%s'", c.Method)
 	}
 
-	if c.Method == Recursive && c.Overlap != 0 {
-		fmt.Printf("warning: currently Recursive chopper doesn't support overlap, setting overlap to 0\n")
-		c.Overlap = 0
+	switch c.InputFormat {
+	case "", InputText:
+	case InputJSONL:
+		if c.TextField == "" {
+			return fmt.Errorf("text field is required for jsonl input")
+		}
+	case InputCSV, InputTSV:
+		if c.TextColumns == "" && c.Method != Table && c.Method != Auto {
+			return fmt.Errorf("text columns are required for csv input, unless the method is table")
+		}
+	default:
+		return fmt.Errorf("invalid input format: '%s'", c.InputFormat)
+	}
+
+	if c.GroupBy != "" && c.InputFormat != InputCSV && c.InputFormat != InputTSV {
+		return fmt.Errorf("group-by requires csv or tsv input")
 	}
 
-	if c.Method == Markdown {
+	if c.Embed {
+		if err := c.validateEmbed(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.ParseMethodMap(); err != nil {
+		return err
+	}
+
+	if c.Method == Markdown || c.Method == HTML || c.Method == Auto {
 		if err := c.ParseMarkdownHeader(); err != nil {
 			return err
 		}
@@ -119,3 +379,83 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// ValidateChunking checks the options used by choppers without changing the
+// config, unlike Validate it doesn't need inputs or outputs.
+func (c *Config) ValidateChunking() error {
+	if c.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size must be greater than 0")
+	}
+
+	if c.Overlap >= c.ChunkSize {
+		return fmt.Errorf("overlap must be less than chunk size")
+	}
+
+	validLengthUnits := map[LengthUnit]bool{
+		"":     true,
+		Runes:  true,
+		Bytes:  true,
+		Words:  true,
+		Tokens: true,
+	}
+	if !validLengthUnits[c.LengthUnit] {
+		return fmt.Errorf("invalid length unit: '%s'", c.LengthUnit)
+	}
+
+	if (c.Method == Token || c.LengthUnit == Tokens) && !validTokenizers[c.Tokenizer] {
+		return fmt.Errorf("invalid tokenizer: '%s'", c.Tokenizer)
+	}
+
+	if c.SeparatorRegex {
+		for _, sep := range c.Separators {
+			if _, err := regexp.Compile(sep); err != nil {
+				return fmt.Errorf("invalid separator regex '%s': %w", sep, err)
+			}
+		}
+	}
+
+	if len(c.Separators) > 0 && c.Language != "" {
+		return fmt.Errorf("separators and language can't be used together")
+	}
+
+	if c.Language != "" && !validLanguages[c.Language] {
+		return fmt.Errorf("invalid language: '%s'", c.Language)
+	}
+
+	switch c.KeepSeparator {
+	case "", KeepStart, KeepEnd, KeepNone:
+	default:
+		return fmt.Errorf("invalid keep separator mode: '%s'", c.KeepSeparator)
+	}
+
+	switch c.IDScheme {
+	case "", IDNone, IDUUIDv5, IDSHA256, IDOrdinal:
+	default:
+		return fmt.Errorf("invalid id scheme: '%s'", c.IDScheme)
+	}
+
+	return nil
+}
+
+func (c *Config) validateEmbed() error {
+	endpoint, err := url.Parse(c.EmbedURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("invalid embedding url: '%s'", c.EmbedURL)
+	}
+	if c.EmbedModel == "" {
+		return fmt.Errorf("embedding model is required")
+	}
+	if c.EmbedBatchSize <= 0 {
+		return fmt.Errorf("embedding batch size must be greater than 0")
+	}
+	if c.EmbedWorkers <= 0 {
+		return fmt.Errorf("embedding workers must be greater than 0")
+	}
+	if c.EmbedRate < 0 {
+		return fmt.Errorf("embedding rate must not be negative")
+	}
+	if c.EmbedRetries < 0 {
+		return fmt.Errorf("embedding retries must not be negative")
+	}
+	return nil
+}
@@ -4,10 +4,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewConfig(t *testing.T) {
 	cfg := NewConfig()
+	assert.Equal(t, Char, cfg.Method)
 	assert.Equal(t, 1000, cfg.ChunkSize)
 	assert.Equal(t, CleanNone, cfg.CleaningMode)
 	assert.Equal(t, 0, cfg.Overlap)
@@ -16,6 +18,37 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, cfg.MarkdownLevels)
 	assert.Equal(t, false, cfg.StripHeaders)
 	assert.Equal(t, false, cfg.AddMetadata)
+	assert.Equal(t, "cl100k_base", cfg.Tokenizer)
+	assert.Equal(t, KeepStart, cfg.KeepSeparator)
+	assert.Equal(t, InputText, cfg.InputFormat)
+	assert.Equal(t, "text", cfg.TextField)
+	assert.Equal(t, SchemaChopdoc, cfg.Schema)
+	assert.Equal(t, false, cfg.Embed)
+	assert.Equal(t, "https://api.openai.com/v1", cfg.EmbedURL)
+	assert.Equal(t, 64, cfg.EmbedBatchSize)
+}
+
+func TestOutputFormatFromExtension(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected OutputFormat
+	}{
+		{output: "", expected: FormatJSONL},
+		{output: "chunks.jsonl", expected: FormatJSONL},
+		{output: "chunks.JSON", expected: FormatJSON},
+		{output: "chunks.csv", expected: FormatCSV},
+		{output: "chunks.parquet", expected: FormatParquet},
+		{output: "chunks.sqlite", expected: FormatSQLite},
+		{output: "chunks.db", expected: FormatSQLite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			cfg := Config{InputFile: "input.txt", OutputFile: tt.output, Method: Char, ChunkSize: 100}
+			require.NoError(t, cfg.Validate())
+			assert.Equal(t, tt.expected, cfg.OutputFormat)
+		})
+	}
 }
 
 func TestValidate(t *testing.T) {
@@ -42,6 +75,46 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: "input file is required",
 		},
+		{
+			name: "multiple inputs",
+			cfg: Config{
+				Inputs:    []string{"docs", "notes/*.md"},
+				OutputDir: "out",
+				Method:    Char,
+				ChunkSize: 1000,
+			},
+		},
+		{
+			name: "negative workers",
+			cfg: Config{
+				Inputs:    []string{"docs"},
+				Method:    Char,
+				ChunkSize: 1000,
+				Workers:   -1,
+			},
+			wantErr: "workers must not be negative",
+		},
+		{
+			name: "output and output dir",
+			cfg: Config{
+				Inputs:     []string{"docs"},
+				OutputFile: "output.jsonl",
+				OutputDir:  "out",
+				Method:     Char,
+				ChunkSize:  1000,
+			},
+			wantErr: "output and output-dir can't be used together",
+		},
+		{
+			name: "output dir with piped input",
+			cfg: Config{
+				Piped:     true,
+				OutputDir: "out",
+				Method:    Char,
+				ChunkSize: 1000,
+			},
+			wantErr: "output-dir requires input files",
+		},
 		{
 			name: "input can be empty when piped",
 			cfg: Config{
@@ -60,14 +133,93 @@ func TestValidate(t *testing.T) {
 			},
 		},
 		{
-			name: "non empty output must include jsonl",
+			name: "output format from extension",
 			cfg: Config{
 				Piped:      true,
-				OutputFile: "output.json",
+				OutputFile: "output.parquet",
 				ChunkSize:  1000,
 				Method:     Char,
 			},
-			wantErr: "output file must have .jsonl extension",
+		},
+		{
+			name: "output format overrides extension",
+			cfg: Config{
+				Piped:        true,
+				OutputFile:   "output.txt",
+				OutputFormat: FormatCSV,
+				ChunkSize:    1000,
+				Method:       Char,
+			},
+		},
+		{
+			name: "sqlite output to stdout",
+			cfg: Config{
+				Piped:        true,
+				OutputFormat: FormatSQLite,
+				ChunkSize:    1000,
+				Method:       Char,
+			},
+			wantErr: "sqlite output requires an output file",
+		},
+		{
+			name: "langchain schema",
+			cfg: Config{
+				Piped:     true,
+				Schema:    SchemaLangChain,
+				ChunkSize: 1000,
+				Method:    Char,
+			},
+		},
+		{
+			name: "schema with csv output",
+			cfg: Config{
+				Piped:      true,
+				OutputFile: "chunks.csv",
+				Schema:     SchemaHaystack,
+				ChunkSize:  1000,
+				Method:     Char,
+			},
+			wantErr: "schema haystack is supported by jsonl and json output formats only",
+		},
+		{
+			name: "custom schema without template",
+			cfg: Config{
+				Piped:     true,
+				Schema:    SchemaCustom,
+				ChunkSize: 1000,
+				Method:    Char,
+			},
+			wantErr: "custom schema and template must be used together",
+		},
+		{
+			name: "template without custom schema",
+			cfg: Config{
+				Piped:          true,
+				RecordTemplate: `{"text": {{json .Text}}}`,
+				ChunkSize:      1000,
+				Method:         Char,
+			},
+			wantErr: "custom schema and template must be used together",
+		},
+		{
+			name: "invalid schema",
+			cfg: Config{
+				Piped:     true,
+				Schema:    Schema("openai"),
+				ChunkSize: 1000,
+				Method:    Char,
+			},
+			wantErr: "invalid schema: 'openai'",
+		},
+		{
+			name: "invalid output format",
+			cfg: Config{
+				Piped:        true,
+				OutputFormat: OutputFormat("xml"),
+				ChunkSize:    1000,
+				Method:       Char,
+			},
+			wantErr: "invalid output format: 'xml'",
 		},
 		{
 			name: "invalid chunk size",
@@ -95,7 +247,7 @@ func TestValidate(t *testing.T) {
 				OutputFile: "output.txt",
 				ChunkSize:  1000,
 			},
-			wantErr: "output file must have .jsonl extension",
+			wantErr: "unknown output format of output.txt, expected .jsonl, .json, .csv, .parquet or .sqlite extension or format",
 		},
 		{
 			name: "valid markdown config",
@@ -204,7 +356,275 @@ func TestValidate(t *testing.T) {
 			wantErr: "invalid chunking method: ''",
 		},
 		{
-			name: "recursive with overlap shows warning",
+			name: "valid token config",
+			cfg: Config{
+				InputFile: "input.txt",
+				Method:    Token,
+				ChunkSize: 512,
+				Overlap:   64,
+				Tokenizer: "o200k_base",
+			},
+		},
+		{
+			name: "invalid tokenizer",
+			cfg: Config{
+				InputFile: "input.txt",
+				Method:    Token,
+				ChunkSize: 512,
+				Tokenizer: "gpt2",
+			},
+			wantErr: "invalid tokenizer: 'gpt2'",
+		},
+		{
+			name: "valid length unit",
+			cfg: Config{
+				InputFile:  "input.txt",
+				Method:     Recursive,
+				ChunkSize:  512,
+				LengthUnit: Tokens,
+				Tokenizer:  "cl100k_base",
+			},
+		},
+		{
+			name: "invalid length unit",
+			cfg: Config{
+				InputFile:  "input.txt",
+				Method:     Recursive,
+				ChunkSize:  512,
+				LengthUnit: LengthUnit("lines"),
+			},
+			wantErr: "invalid length unit: 'lines'",
+		},
+		{
+			name: "tokens length unit requires valid tokenizer",
+			cfg: Config{
+				InputFile:  "input.txt",
+				Method:     Sentence,
+				ChunkSize:  512,
+				LengthUnit: Tokens,
+			},
+			wantErr: "invalid tokenizer: ''",
+		},
+		{
+			name: "length unit ignored for char method",
+			cfg: Config{
+				InputFile:  "input.txt",
+				Method:     Char,
+				ChunkSize:  512,
+				LengthUnit: Words,
+			},
+		},
+		{
+			name: "valid separators with language preset",
+			cfg: Config{
+				InputFile: "input.go",
+				Method:    Recursive,
+				ChunkSize: 512,
+				Language:  "go",
+			},
+		},
+		{
+			name: "invalid language",
+			cfg: Config{
+				InputFile: "input.txt",
+				Method:    Recursive,
+				ChunkSize: 512,
+				Language:  "cobol",
+			},
+			wantErr: "invalid language: 'cobol'",
+		},
+		{
+			name: "separators and language are exclusive",
+			cfg: Config{
+				InputFile:     "input.txt",
+				Method:        Recursive,
+				ChunkSize:     512,
+				SeparatorList: "\\n",
+				Language:      "go",
+			},
+			wantErr: "separators and language can't be used together",
+		},
+		{
+			name: "invalid separator regex",
+			cfg: Config{
+				InputFile:      "input.txt",
+				Method:         Recursive,
+				ChunkSize:      512,
+				SeparatorList:  "(",
+				SeparatorRegex: true,
+			},
+			wantErr: "invalid separator regex '(': error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "invalid keep separator",
+			cfg: Config{
+				InputFile:     "input.txt",
+				Method:        Recursive,
+				ChunkSize:     512,
+				KeepSeparator: KeepSeparator("middle"),
+			},
+			wantErr: "invalid keep separator mode: 'middle'",
+		},
+		{
+			name: "valid id scheme",
+			cfg: Config{
+				InputFile: "input.txt",
+				Method:    Char,
+				ChunkSize: 512,
+				IDScheme:  IDUUIDv5,
+			},
+		},
+		{
+			name: "invalid id scheme",
+			cfg: Config{
+				InputFile: "input.txt",
+				Method:    Char,
+				ChunkSize: 512,
+				IDScheme:  IDScheme("random"),
+			},
+			wantErr: "invalid id scheme: 'random'",
+		},
+		{
+			name: "jsonl input",
+			cfg: Config{
+				InputFile:   "records.jsonl",
+				Method:      Recursive,
+				ChunkSize:   512,
+				InputFormat: InputJSONL,
+				TextField:   "body",
+			},
+		},
+		{
+			name: "jsonl input without text field",
+			cfg: Config{
+				InputFile:   "records.jsonl",
+				Method:      Recursive,
+				ChunkSize:   512,
+				InputFormat: InputJSONL,
+			},
+			wantErr: "text field is required for jsonl input",
+		},
+		{
+			name: "csv input",
+			cfg: Config{
+				InputFile:   "faq.csv",
+				Method:      Recursive,
+				ChunkSize:   512,
+				InputFormat: InputCSV,
+				TextColumns: "Q: {question}\nA: {answer}",
+				GroupBy:     "topic",
+			},
+		},
+		{
+			name: "csv input with table method",
+			cfg: Config{
+				InputFile:   "faq.csv",
+				Method:      Table,
+				ChunkSize:   20,
+				InputFormat: InputCSV,
+			},
+		},
+		{
+			name: "csv input without text columns",
+			cfg: Config{
+				InputFile:   "faq.csv",
+				Method:      Recursive,
+				ChunkSize:   512,
+				InputFormat: InputCSV,
+			},
+			wantErr: "text columns are required for csv input, unless the method is table",
+		},
+		{
+			name: "group by without csv input",
+			cfg: Config{
+				InputFile: "faq.txt",
+				Method:    Recursive,
+				ChunkSize: 512,
+				GroupBy:   "topic",
+			},
+			wantErr: "group-by requires csv or tsv input",
+		},
+		{
+			name: "invalid input format",
+			cfg: Config{
+				InputFile:   "records.xml",
+				Method:      Recursive,
+				ChunkSize:   512,
+				InputFormat: InputFormat("xml"),
+			},
+			wantErr: "invalid input format: 'xml'",
+		},
+		{
+			name: "embed",
+			cfg: Config{
+				InputFile:      "input.txt",
+				Method:         Recursive,
+				ChunkSize:      512,
+				Embed:          true,
+				EmbedURL:       "http://localhost:11434/v1",
+				EmbedModel:     "nomic-embed-text",
+				EmbedBatchSize: 16,
+				EmbedWorkers:   2,
+				EmbedRate:      0.5,
+			},
+		},
+		{
+			name: "embed with invalid url",
+			cfg: Config{
+				InputFile:      "input.txt",
+				Method:         Recursive,
+				ChunkSize:      512,
+				Embed:          true,
+				EmbedURL:       "localhost:11434",
+				EmbedModel:     "nomic-embed-text",
+				EmbedBatchSize: 16,
+				EmbedWorkers:   2,
+			},
+			wantErr: "invalid embedding url: 'localhost:11434'",
+		},
+		{
+			name: "embed without model",
+			cfg: Config{
+				InputFile:      "input.txt",
+				Method:         Recursive,
+				ChunkSize:      512,
+				Embed:          true,
+				EmbedURL:       "http://localhost:11434/v1",
+				EmbedBatchSize: 16,
+				EmbedWorkers:   2,
+			},
+			wantErr: "embedding model is required",
+		},
+		{
+			name: "embed with zero batch size",
+			cfg: Config{
+				InputFile:    "input.txt",
+				Method:       Recursive,
+				ChunkSize:    512,
+				Embed:        true,
+				EmbedURL:     "http://localhost:11434/v1",
+				EmbedModel:   "nomic-embed-text",
+				EmbedWorkers: 2,
+			},
+			wantErr: "embedding batch size must be greater than 0",
+		},
+		{
+			name: "embed with negative rate",
+			cfg: Config{
+				InputFile:      "input.txt",
+				Method:         Recursive,
+				ChunkSize:      512,
+				Embed:          true,
+				EmbedURL:       "http://localhost:11434/v1",
+				EmbedModel:     "nomic-embed-text",
+				EmbedBatchSize: 16,
+				EmbedWorkers:   2,
+				EmbedRate:      -1,
+			},
+			wantErr: "embedding rate must not be negative",
+		},
+		{
+			name: "recursive with overlap",
 			cfg: Config{
 				InputFile:  "input.txt",
 				OutputFile: "output.jsonl",
@@ -233,8 +653,12 @@ func TestValidate(t *testing.T) {
 				}
 			}
 
-			if tt.cfg.Method == Recursive && tt.wantErr == "" && tt.cfg.Overlap != 0 {
-				assert.Equal(t, 0, tt.cfg.Overlap, "Recursive method should reset overlap to 0")
+			if tt.name == "length unit ignored for char method" {
+				assert.Equal(t, Words, tt.cfg.LengthUnit, "Validate should keep length unit")
+			}
+
+			if tt.cfg.Method == Recursive && tt.wantErr == "" && tt.name == "recursive with overlap" {
+				assert.Equal(t, 50, tt.cfg.Overlap, "Recursive method should keep overlap")
 			}
 		})
 	}
@@ -321,3 +745,76 @@ func TestParseMarkdownHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSeparators(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "empty",
+			list: "",
+			want: nil,
+		},
+		{
+			name: "comma separated with escapes",
+			list: `\n\n,\n, ,`,
+			want: []string{"\n\n", "\n", " ", ""},
+		},
+		{
+			name: "comma separated without escapes",
+			list: "###,##,#",
+			want: []string{"###", "##", "#"},
+		},
+		{
+			name: "json list",
+			list: `["\n\n", ",", "\t"]`,
+			want: []string{"\n\n", ",", "\t"},
+		},
+		{
+			name:    "invalid json list",
+			list:    `["\n\n",`,
+			wantErr: "invalid separators list: unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{SeparatorList: tt.list}
+			err := cfg.ParseSeparators()
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Separators)
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected []string
+	}{
+		{name: "no length unit", cfg: Config{Method: Char}},
+		{name: "length unit used", cfg: Config{Method: Recursive, LengthUnit: Words}},
+		{name: "length unit used by auto", cfg: Config{Method: Auto, LengthUnit: Tokens}},
+		{
+			name:     "length unit ignored",
+			cfg:      Config{Method: Char, LengthUnit: Words},
+			expected: []string{"length unit is used only by recursive, sentence, markdown and html choppers, ignoring it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cfg.Warnings())
+		})
+	}
+}
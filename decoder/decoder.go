@@ -0,0 +1,62 @@
+package decoder
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Document is the text of a decoded input, paged formats like PDF keep the
+// text of every page in Pages.
+type Document struct {
+	Text  string
+	Pages []string
+}
+
+type DecodeFunc func(r io.Reader) (*Document, error)
+
+// Format is an input format which needs decoding before it can be chopped,
+// it's recognized by the file extension or by sniffing the start of the
+// input.
+type Format struct {
+	Name       string
+	Extensions []string
+	Sniff      func(head []byte) bool
+	Decode     DecodeFunc
+}
+
+var formats = []*Format{
+	{Name: "pdf", Extensions: []string{".pdf"}, Sniff: HasMagic("%PDF-"), Decode: DecodePDF},
+	{Name: "docx", Extensions: []string{".docx"}, Sniff: sniffDOCX, Decode: DecodeDOCX},
+	{Name: "odt", Extensions: []string{".odt"}, Sniff: zipMimetype("application/vnd.oasis.opendocument.text"), Decode: DecodeODT},
+	{Name: "epub", Extensions: []string{".epub"}, Sniff: zipMimetype("application/epub+zip"), Decode: DecodeEPUB},
+}
+
+// Detect returns the format of the input at path starting with head, or nil
+// for plain text inputs.
+func Detect(path string, head []byte) *Format {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, format := range formats {
+		if ext != "" && slices.Contains(format.Extensions, ext) {
+			return format
+		}
+	}
+
+	for _, format := range formats {
+		if format.Sniff != nil && format.Sniff(head) {
+			return format
+		}
+	}
+
+	return nil
+}
+
+// HasMagic returns a sniff function recognizing inputs which start with
+// magic.
+func HasMagic(magic string) func(head []byte) bool {
+	return func(head []byte) bool {
+		return bytes.HasPrefix(head, []byte(magic))
+	}
+}
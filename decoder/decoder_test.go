@@ -0,0 +1,48 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	mimetypeZip := func(mimetype string) string {
+		return string(buildZip(t, zipEntry{"mimetype", mimetype}, zipEntry{"content.xml", "<a/>"}))
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		head     string
+		expected string
+	}{
+		{name: "pdf extension", path: "docs/report.pdf", expected: "pdf"},
+		{name: "upper case extension", path: "REPORT.PDF", expected: "pdf"},
+		{name: "pdf magic", path: "", head: "%PDF-1.7\n", expected: "pdf"},
+		{name: "pdf magic with other extension", path: "report.bin", head: "%PDF-1.7\n", expected: "pdf"},
+		{name: "docx extension", path: "letter.docx", expected: "docx"},
+		{name: "odt magic", head: mimetypeZip("application/vnd.oasis.opendocument.text"), expected: "odt"},
+		{name: "epub magic", head: mimetypeZip("application/epub+zip"), expected: "epub"},
+		{name: "docx magic", head: string(buildZip(t, zipEntry{"[Content_Types].xml", "<Types/>"}, zipEntry{"word/document.xml", "<w:document/>"})), expected: "docx"},
+		{name: "docx magic without word parts", head: string(buildZip(t, zipEntry{"[Content_Types].xml", strings.Repeat("<Types/>", 200)})), expected: "docx"},
+		{name: "xlsx", head: string(buildZip(t, zipEntry{"[Content_Types].xml", "<Types/>"}, zipEntry{"xl/workbook.xml", "<workbook/>"}))},
+		{name: "zip without mimetype", head: string(buildZip(t, zipEntry{"readme.txt", "hello"}))},
+		{name: "plain text", path: "notes.txt", head: "hello"},
+		{name: "piped text", path: "", head: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := Detect(tt.path, []byte(tt.head))
+			if tt.expected == "" {
+				assert.Nil(t, format)
+				return
+			}
+			require.NotNil(t, format)
+			assert.Equal(t, tt.expected, format.Name)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package decoder
+
+import "strings"
+
+// docBuilder builds the markdown of word processor documents. Paragraphs can
+// nest, e.g. in text boxes, and tables can contain tables.
+type docBuilder struct {
+	out        markdownWriter
+	paragraphs []*docParagraph
+	tables     []*docTable
+}
+
+type docParagraph struct {
+	text strings.Builder
+	// header level, 0 for body text
+	heading int
+	// list nesting level starting at 1, 0 when not a list item
+	list int
+}
+
+type docTable struct {
+	rows [][]string
+	row  []string
+	cell []string
+}
+
+func (b *docBuilder) startParagraph() *docParagraph {
+	p := &docParagraph{}
+	b.paragraphs = append(b.paragraphs, p)
+	return p
+}
+
+// paragraph returns the innermost open paragraph, or nil.
+func (b *docBuilder) paragraph() *docParagraph {
+	if len(b.paragraphs) == 0 {
+		return nil
+	}
+	return b.paragraphs[len(b.paragraphs)-1]
+}
+
+// write adds text to the open paragraph, text outside paragraphs is dropped.
+func (b *docBuilder) write(text string) {
+	if p := b.paragraph(); p != nil {
+		p.text.WriteString(text)
+	}
+}
+
+func (b *docBuilder) endParagraph() {
+	p := b.paragraph()
+	if p == nil {
+		return
+	}
+	b.paragraphs = b.paragraphs[:len(b.paragraphs)-1]
+
+	if table := b.table(); table != nil {
+		table.cell = append(table.cell, p.text.String())
+		return
+	}
+
+	switch {
+	case p.heading > 0:
+		b.out.heading(p.heading, p.text.String())
+	case p.list > 0:
+		b.out.listItem(p.list-1, p.text.String())
+	default:
+		b.out.paragraph(p.text.String())
+	}
+}
+
+func (b *docBuilder) table() *docTable {
+	if len(b.tables) == 0 {
+		return nil
+	}
+	return b.tables[len(b.tables)-1]
+}
+
+func (b *docBuilder) startTable() {
+	b.tables = append(b.tables, &docTable{})
+}
+
+func (b *docBuilder) startRow() {
+	if table := b.table(); table != nil {
+		table.row = nil
+	}
+}
+
+func (b *docBuilder) startCell() {
+	if table := b.table(); table != nil {
+		table.cell = nil
+	}
+}
+
+func (b *docBuilder) endCell() {
+	if table := b.table(); table != nil {
+		table.row = append(table.row, strings.Join(strings.Fields(strings.Join(table.cell, " ")), " "))
+		table.cell = nil
+	}
+}
+
+func (b *docBuilder) endRow() {
+	if table := b.table(); table != nil && len(table.row) > 0 {
+		table.rows = append(table.rows, table.row)
+		table.row = nil
+	}
+}
+
+// endTable renders the table, a nested table becomes text of the enclosing
+// cell.
+func (b *docBuilder) endTable() {
+	table := b.table()
+	if table == nil {
+		return
+	}
+	b.tables = b.tables[:len(b.tables)-1]
+
+	parent := b.table()
+	if parent == nil {
+		b.out.table(table.rows)
+		return
+	}
+	for _, row := range table.rows {
+		parent.cell = append(parent.cell, row...)
+	}
+}
@@ -0,0 +1,201 @@
+package decoder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var headingStyleRgx = regexp.MustCompile(`^(?i)heading ?([1-6])$`)
+
+type docxValue struct {
+	Val string `xml:"val,attr"`
+}
+
+type docxParagraphProperties struct {
+	Style      docxValue  `xml:"pStyle"`
+	OutlineLvl *docxValue `xml:"outlineLvl"`
+	NumPr      *struct {
+		Ilvl  docxValue `xml:"ilvl"`
+		NumID docxValue `xml:"numId"`
+	} `xml:"numPr"`
+}
+
+type docxStyles struct {
+	Styles []struct {
+		ID      string    `xml:"styleId,attr"`
+		Name    docxValue `xml:"name"`
+		BasedOn docxValue `xml:"basedOn"`
+		PPr     struct {
+			OutlineLvl *docxValue `xml:"outlineLvl"`
+		} `xml:"pPr"`
+	} `xml:"style"`
+}
+
+// sniffDOCX detects Office Open XML documents by the members whose headers
+// are in head: they start with [Content_Types].xml and the parts of Word
+// documents are below word/, unlike those of spreadsheets and presentations.
+func sniffDOCX(head []byte) bool {
+	members := zipMembers(head)
+	for _, member := range members {
+		if strings.HasPrefix(member, "word/") {
+			return true
+		}
+		if strings.HasPrefix(member, "xl/") || strings.HasPrefix(member, "ppt/") {
+			return false
+		}
+	}
+	return len(members) > 0 && members[0] == "[Content_Types].xml"
+}
+
+// DecodeDOCX extracts the text of an Office Open XML document as markdown,
+// paragraphs with Heading 1-6 styles or outline levels become headers.
+func DecodeDOCX(r io.Reader) (*Document, error) {
+	archive, err := openZip(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docx: %w", err)
+	}
+
+	var styles map[string]int
+	if data, err := readZipFile(archive, "word/styles.xml"); err == nil {
+		if styles, err = docxHeadingStyles(data); err != nil {
+			return nil, fmt.Errorf("failed to read docx styles: %w", err)
+		}
+	}
+
+	data, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docx: %w", err)
+	}
+
+	var b docBuilder
+	err = xmlTokens(data, func(d *xml.Decoder, token xml.Token) error {
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				b.startParagraph()
+			case "pPr":
+				var props docxParagraphProperties
+				if err := d.DecodeElement(&props, &t); err != nil {
+					return err
+				}
+				if p := b.paragraph(); p != nil {
+					p.heading = docxHeading(props, styles)
+					if props.NumPr != nil && props.NumPr.NumID.Val != "0" {
+						level, _ := strconv.Atoi(props.NumPr.Ilvl.Val)
+						p.list = level + 1
+					}
+				}
+			case "t":
+				var text string
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return err
+				}
+				b.write(text)
+			case "tab":
+				b.write("\t")
+			case "br", "cr":
+				b.write("\n")
+			case "tbl":
+				b.startTable()
+			case "tr":
+				b.startRow()
+			case "tc":
+				b.startCell()
+			case "rPr", "tblPr", "trPr", "tcPr", "tblGrid", "sectPr", "Fallback":
+				// properties hold no text, fallbacks repeat alternate content
+				return d.Skip()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				b.endParagraph()
+			case "tc":
+				b.endCell()
+			case "tr":
+				b.endRow()
+			case "tbl":
+				b.endTable()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docx: %w", err)
+	}
+
+	return &Document{Text: b.out.String()}, nil
+}
+
+func docxHeading(props docxParagraphProperties, styles map[string]int) int {
+	if props.OutlineLvl != nil {
+		return outlineHeading(props.OutlineLvl.Val)
+	}
+	if level, ok := styles[props.Style.Val]; ok {
+		return level
+	}
+	// documents without styles part use the built in style ids
+	if m := headingStyleRgx.FindStringSubmatch(props.Style.Val); m != nil {
+		level, _ := strconv.Atoi(m[1])
+		return level
+	}
+	return 0
+}
+
+// outlineHeading converts a zero based outline level to a header level, body
+// text has level 9.
+func outlineHeading(value string) int {
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 || level > 5 {
+		return 0
+	}
+	return level + 1
+}
+
+// docxHeadingStyles maps paragraph style ids to header levels, following the
+// styles they are based on.
+func docxHeadingStyles(data []byte) (map[string]int, error) {
+	var parsed docxStyles
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]int, len(parsed.Styles))
+	for i, style := range parsed.Styles {
+		byID[style.ID] = i
+	}
+
+	var level func(id string, depth int) int
+	level = func(id string, depth int) int {
+		i, ok := byID[id]
+		if !ok || depth > len(parsed.Styles) {
+			return 0
+		}
+		style := parsed.Styles[i]
+
+		if m := headingStyleRgx.FindStringSubmatch(strings.TrimSpace(style.Name.Val)); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n
+		}
+		if style.PPr.OutlineLvl != nil {
+			return outlineHeading(style.PPr.OutlineLvl.Val)
+		}
+		if style.BasedOn.Val != "" {
+			return level(style.BasedOn.Val, depth+1)
+		}
+		return 0
+	}
+
+	styles := make(map[string]int)
+	for _, style := range parsed.Styles {
+		if n := level(style.ID, 0); n > 0 {
+			styles[style.ID] = n
+		}
+	}
+
+	return styles, nil
+}
@@ -0,0 +1,100 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type epubPackage struct {
+	Manifest []epubItem `xml:"manifest>item"`
+	Spine    []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// DecodeEPUB extracts the text of the chapters of an EPUB book in reading
+// order as markdown, chapter headings become headers.
+func DecodeEPUB(r io.Reader) (*Document, error) {
+	archive, err := openZip(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read epub: %w", err)
+	}
+
+	data, err := readZipFile(archive, "META-INF/container.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read epub: %w", err)
+	}
+	var container epubContainer
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return nil, fmt.Errorf("failed to read epub container: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("failed to read epub: no package document")
+	}
+
+	packagePath := container.Rootfiles[0].FullPath
+	data, err = readZipFile(archive, packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read epub: %w", err)
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to read epub package: %w", err)
+	}
+
+	items := make(map[string]epubItem, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		items[item.ID] = item
+	}
+
+	var chapters []string
+	for _, ref := range pkg.Spine {
+		item, ok := items[ref.IDRef]
+		if !ok {
+			continue
+		}
+
+		// the navigation document repeats the chapter titles
+		if !strings.Contains(item.MediaType, "html") || slices.Contains(strings.Fields(item.Properties), "nav") {
+			continue
+		}
+
+		href, err := url.PathUnescape(strings.SplitN(item.Href, "#", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read epub chapter %s: %w", item.Href, err)
+		}
+		data, err := readZipFile(archive, path.Join(path.Dir(packagePath), href))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read epub chapter %s: %w", item.Href, err)
+		}
+
+		text, err := HTMLToMarkdown(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read epub chapter %s: %w", item.Href, err)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			chapters = append(chapters, text)
+		}
+	}
+
+	return &Document{Text: joinBlocks(chapters)}, nil
+}
@@ -0,0 +1,293 @@
+package decoder
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// boilerplate elements never contain document text
+var boilerplate = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Body:       true,
+	atom.Dd:         true,
+	atom.Details:    true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Fieldset:   true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.Form:       true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.P:          true,
+	atom.Section:    true,
+	atom.Summary:    true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1,
+	atom.H2: 2,
+	atom.H3: 3,
+	atom.H4: 4,
+	atom.H5: 5,
+	atom.H6: 6,
+}
+
+var spaceRgx = regexp.MustCompile(`\s+`)
+
+// HTMLToMarkdown extracts the text of an HTML document as markdown blocks
+// separated by blank lines.
+func HTMLToMarkdown(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	return joinBlocks(renderBlocks(doc)), nil
+}
+
+// htmlWriter collects the markdown blocks of a node tree, inline text is
+// buffered until a block element ends the paragraph.
+type htmlWriter struct {
+	blocks []string
+	inline strings.Builder
+}
+
+func renderBlocks(n *html.Node) []string {
+	w := &htmlWriter{}
+	w.children(n)
+	w.flush()
+	return w.blocks
+}
+
+func (w *htmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *htmlWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// line breaks of the source are spaces, runs are collapsed on flush
+		w.inline.WriteString(spaceRgx.ReplaceAllString(n.Data, " "))
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if boilerplate[n.DataAtom] || attr(n, "role") == "navigation" || attr(n, "aria-hidden") == "true" {
+		return
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		w.flush()
+		if title := inlineText(n); title != "" {
+			w.blocks = append(w.blocks, markdownHeading(level, title))
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.inline.WriteString("\n")
+	case atom.Code, atom.Kbd, atom.Samp:
+		if text := inlineText(n); text != "" {
+			w.inline.WriteString("`" + text + "`")
+		}
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			w.inline.WriteString(alt)
+		}
+	case atom.Pre:
+		w.flush()
+		w.add(codeBlock(n))
+	case atom.Ul, atom.Ol:
+		w.flush()
+		w.add(listBlock(n))
+	case atom.Table:
+		w.flush()
+		w.add(tableBlock(n))
+	case atom.Blockquote:
+		w.flush()
+		w.add(quoteBlock(n))
+	default:
+		if blockElements[n.DataAtom] {
+			w.flush()
+			w.children(n)
+			w.flush()
+			return
+		}
+		w.children(n)
+	}
+}
+
+func (w *htmlWriter) add(block string) {
+	if block != "" {
+		w.blocks = append(w.blocks, block)
+	}
+}
+
+// flush ends the current paragraph, lines which look like markdown syntax are
+// escaped so they stay text.
+func (w *htmlWriter) flush() {
+	var lines []string
+	for _, line := range strings.Split(w.inline.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		lines = append(lines, escapeMarkdown(line))
+	}
+	w.inline.Reset()
+
+	w.add(strings.Join(lines, "\n"))
+}
+
+// inlineText renders the content of n as a single line.
+func inlineText(n *html.Node) string {
+	return strings.Join(strings.Fields(strings.Join(renderBlocks(n), " ")), " ")
+}
+
+func codeBlock(n *html.Node) string {
+	var text strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Br {
+			text.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+
+	code := strings.TrimRight(strings.TrimPrefix(text.String(), "\n"), " \t\r\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + code + "\n" + fence
+}
+
+// listBlock renders list items with "-" or numbered markers, content of an
+// item, including nested lists, is indented under its marker.
+func listBlock(n *html.Node) string {
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	var items []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		content := strings.Join(renderBlocks(c), "\n")
+		if content == "" {
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.ReplaceAll(content, "\n", "\n"+indent))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// tableBlock renders a table as a markdown pipe table.
+func tableBlock(n *html.Node) string {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						row = append(row, inlineText(cell))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	collect(n)
+
+	return markdownTable(rows)
+}
+
+func quoteBlock(n *html.Node) string {
+	content := strings.Join(renderBlocks(n), "\n\n")
+	if content == "" {
+		return ""
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
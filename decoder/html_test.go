@@ -0,0 +1,73 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "boilerplate is dropped",
+			input: `<html><head><title>Page</title><style>p {}</style></head><body>
+<nav><a href="/">Home</a></nav>
+<div role="navigation">Menu</div>
+<script>var x = 1;</script>
+<p>Hello <b>world</b>!</p>
+<noscript>Enable JavaScript</noscript>
+</body></html>`,
+			expected: "Hello world!\n",
+		},
+		{
+			name:     "headings and paragraphs",
+			input:    "<h1>Title</h1><p>intro\n  text</p><h2>Sub <code>x</code></h2><div>one<br>two</div>",
+			expected: "# Title\n\nintro text\n\n## Sub `x`\n\none\ntwo\n",
+		},
+		{
+			name:     "lists",
+			input:    `<ul><li>apple</li><li>pear<ul><li>small</li></ul></li></ul><ol start="3"><li>three</li><li><p>four</p></li></ol>`,
+			expected: "- apple\n- pear\n  - small\n\n3. three\n4. four\n",
+		},
+		{
+			name: "tables",
+			input: `<table><thead><tr><th>Name</th><th>Value</th></tr></thead>
+<tbody><tr><td>a|b</td><td><p>1</p></td></tr><tr><td>short</td></tr></tbody></table>`,
+			expected: "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |\n| short |  |\n",
+		},
+		{
+			name:     "code blocks keep whitespace",
+			input:    "<pre><code>func main() {\n\tfmt.Println(\"```\")\n}\n</code></pre>",
+			expected: "````\nfunc main() {\n\tfmt.Println(\"```\")\n}\n````\n",
+		},
+		{
+			name:     "blockquotes",
+			input:    "<blockquote><p>first</p><p>second</p></blockquote>",
+			expected: "> first\n>\n> second\n",
+		},
+		{
+			name:     "text looking like markdown is escaped",
+			input:    "<p># not a header</p><p>---</p><p>&lt;div&gt;</p>",
+			expected: "\\# not a header\n\n\\---\n\n\\<div>\n",
+		},
+		{
+			name:     "empty document",
+			input:    "<html><body><script>x()</script></body></html>",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := HTMLToMarkdown(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package decoder
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownSyntaxRgx matches text lines which would be read as markdown blocks
+var markdownSyntaxRgx = regexp.MustCompile("^(?:[#>]|<|```|~~~|[-=+]+$)")
+
+// escapeMarkdown escapes a line of text which looks like markdown syntax, so
+// it stays text.
+func escapeMarkdown(line string) string {
+	if markdownSyntaxRgx.MatchString(line) {
+		return `\` + line
+	}
+	return line
+}
+
+// markdownHeading renders a header line, level is clamped to 1-6.
+func markdownHeading(level int, title string) string {
+	return strings.Repeat("#", min(max(level, 1), 6)) + " " + title
+}
+
+// markdownTable renders rows as a markdown pipe table, the first row is the
+// header row and short rows are padded.
+func markdownTable(rows [][]string) string {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j, cell := range row {
+			cells[j] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// joinBlocks joins markdown blocks with blank lines.
+func joinBlocks(blocks []string) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// markdownWriter collects markdown blocks of documents with paragraph
+// structure, like word processor files.
+type markdownWriter struct {
+	blocks []string
+	// the last block is a list, items are added to it
+	list bool
+}
+
+func (w *markdownWriter) heading(level int, title string) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return
+	}
+	w.add(markdownHeading(level, title), false)
+}
+
+// paragraph adds text keeping its line breaks, spaces are collapsed.
+func (w *markdownWriter) paragraph(text string) {
+	if text = paragraphText(text); text != "" {
+		w.add(text, false)
+	}
+}
+
+// listItem adds a "-" item, depth is the nesting level starting at 0.
+func (w *markdownWriter) listItem(depth int, text string) {
+	text = paragraphText(text)
+	if text == "" {
+		return
+	}
+
+	indent := strings.Repeat("  ", depth)
+	item := indent + "- " + strings.ReplaceAll(text, "\n", "\n"+indent+"  ")
+	if w.list {
+		w.blocks[len(w.blocks)-1] += "\n" + item
+		return
+	}
+	w.add(item, true)
+}
+
+func (w *markdownWriter) table(rows [][]string) {
+	if table := markdownTable(rows); table != "" {
+		w.add(table, false)
+	}
+}
+
+func (w *markdownWriter) add(block string, list bool) {
+	w.blocks = append(w.blocks, block)
+	w.list = list
+}
+
+func (w *markdownWriter) String() string {
+	return joinBlocks(w.blocks)
+}
+
+func paragraphText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, escapeMarkdown(line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
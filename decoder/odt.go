@@ -0,0 +1,86 @@
+package decoder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const svgNamespace = "urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0"
+
+// DecodeODT extracts the text of an OpenDocument text document as markdown,
+// headings become headers of their outline level.
+func DecodeODT(r io.Reader) (*Document, error) {
+	archive, err := openZip(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read odt: %w", err)
+	}
+
+	data, err := readZipFile(archive, "content.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read odt: %w", err)
+	}
+
+	var b docBuilder
+	lists := 0
+	err = xmlTokens(data, func(d *xml.Decoder, token xml.Token) error {
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == svgNamespace {
+				// titles and descriptions of drawings
+				return d.Skip()
+			}
+
+			switch t.Name.Local {
+			case "h":
+				p := b.startParagraph()
+				p.heading = 1
+				if level, err := strconv.Atoi(xmlAttr(t, "outline-level")); err == nil {
+					p.heading = level
+				}
+			case "p":
+				p := b.startParagraph()
+				p.list = lists
+			case "list":
+				lists++
+			case "s", "tab":
+				b.write(" ")
+			case "line-break":
+				b.write("\n")
+			case "table":
+				b.startTable()
+			case "table-row":
+				b.startRow()
+			case "table-cell":
+				b.startCell()
+			case "note", "annotation", "tracked-changes", "table-of-content", "covered-table-cell":
+				// notes and tracked changes aren't part of the text flow, a
+				// table of contents repeats the headers
+				return d.Skip()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "h", "p":
+				b.endParagraph()
+			case "list":
+				lists--
+			case "table-cell":
+				b.endCell()
+			case "table-row":
+				b.endRow()
+			case "table":
+				b.endTable()
+			}
+		case xml.CharData:
+			// whitespace is collapsed in OpenDocument text, text:s are spaces
+			b.write(spaceRgx.ReplaceAllString(string(t), " "))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read odt: %w", err)
+	}
+
+	return &Document{Text: b.out.String()}, nil
+}
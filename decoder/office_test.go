@@ -0,0 +1,200 @@
+package decoder
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+}
+
+// buildZip writes entries in order, a leading mimetype entry is stored
+// uncompressed like OpenDocument and EPUB files require.
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		method := zip.Deflate
+		if entry.name == "mimetype" {
+			method = zip.Store
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: entry.name, Method: method})
+		require.NoError(t, err)
+		_, err = f.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+const docxStylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:style w:type="paragraph" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+  <w:style w:type="paragraph" w:styleId="berschrift1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/></w:style>
+  <w:style w:type="paragraph" w:styleId="ChapterTitle"><w:name w:val="Chapter Title"/><w:basedOn w:val="berschrift1"/></w:style>
+  <w:style w:type="paragraph" w:styleId="Summary"><w:name w:val="Summary"/><w:pPr><w:outlineLvl w:val="2"/></w:pPr></w:style>
+</w:styles>`
+
+const docxDocumentXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+  xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006">
+<w:body>
+  <w:p><w:pPr><w:pStyle w:val="ChapterTitle"/></w:pPr><w:r><w:t>Guide</w:t></w:r></w:p>
+  <w:p><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Hello </w:t></w:r><w:r><w:t>world.</w:t></w:r><w:r><w:br/><w:t>Next line</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Heading2"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t>Install</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>Download</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Unpack</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Summary"/></w:pPr><w:r><w:t>Summary</w:t></w:r></w:p>
+  <w:p><w:pPr><w:outlineLvl w:val="3"/></w:pPr><w:r><w:t>Direct outline</w:t></w:r></w:p>
+  <w:tbl>
+    <w:tblPr><w:tblW w:w="0" w:type="auto"/></w:tblPr>
+    <w:tr><w:tc><w:p><w:r><w:t>Flag</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Use</w:t></w:r></w:p></w:tc></w:tr>
+    <w:tr><w:tc><w:p><w:r><w:t>-size</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>chunk</w:t></w:r></w:p><w:p><w:r><w:t>size</w:t></w:r></w:p></w:tc></w:tr>
+  </w:tbl>
+  <w:p><w:r><mc:AlternateContent><mc:Choice><w:t>shape text</w:t></mc:Choice><mc:Fallback><w:t>shape text</w:t></mc:Fallback></mc:AlternateContent></w:r></w:p>
+  <w:p><w:r><w:t># not a header</w:t></w:r></w:p>
+  <w:sectPr><w:pgSz w:w="12240" w:h="15840"/></w:sectPr>
+</w:body>
+</w:document>`
+
+func TestDecodeDOCX(t *testing.T) {
+	data := buildZip(t,
+		zipEntry{"[Content_Types].xml", `<Types/>`},
+		zipEntry{"word/styles.xml", docxStylesXML},
+		zipEntry{"word/document.xml", docxDocumentXML},
+	)
+
+	doc, err := DecodeDOCX(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Guide\n\n"+
+		"Hello world.\nNext line\n\n"+
+		"## Install\n\n"+
+		"- Download\n  - Unpack\n\n"+
+		"### Summary\n\n"+
+		"#### Direct outline\n\n"+
+		"| Flag | Use |\n| --- | --- |\n| -size | chunk size |\n\n"+
+		"shape text\n\n"+
+		"\\# not a header\n", doc.Text)
+}
+
+func TestDecodeDOCXWithoutStyles(t *testing.T) {
+	data := buildZip(t, zipEntry{"word/document.xml", `<w:document xmlns:w="w"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Title</w:t></w:r></w:p>
+<w:p><w:r><w:t>text</w:t></w:r></w:p>
+</w:body></w:document>`})
+
+	doc, err := DecodeDOCX(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "# Title\n\ntext\n", doc.Text)
+}
+
+func TestDecodeODT(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+  xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+  xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+  xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+  xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0">
+<office:body><office:text>
+  <text:sequence-decls><text:sequence-decl text:name="Table"/></text:sequence-decls>
+  <text:table-of-content><text:index-body><text:p>Guide 1</text:p></text:index-body></text:table-of-content>
+  <text:h text:outline-level="1">Guide</text:h>
+  <text:p>Hello
+    <text:span>world</text:span>.<text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>A note.</text:p></text:note-body></text:note><text:line-break/>Next<text:s text:c="3"/>line</text:p>
+  <text:h text:outline-level="2">Install</text:h>
+  <text:list><text:list-item><text:p>Download</text:p>
+    <text:list><text:list-item><text:p>Unpack</text:p></text:list-item></text:list>
+  </text:list-item></text:list>
+  <table:table>
+    <table:table-header-rows><table:table-row><table:table-cell><text:p>Flag</text:p></table:table-cell><table:table-cell><text:p>Use</text:p></table:table-cell></table:table-row></table:table-header-rows>
+    <table:table-row><table:table-cell table:number-columns-spanned="2"><text:p>merged</text:p></table:table-cell><table:covered-table-cell/></table:table-row>
+  </table:table>
+  <text:p><draw:frame><svg:title>Logo</svg:title><draw:text-box><text:p>Boxed text</text:p></draw:text-box></draw:frame>After box</text:p>
+</office:text></office:body>
+</office:document-content>`
+
+	data := buildZip(t,
+		zipEntry{"mimetype", "application/vnd.oasis.opendocument.text"},
+		zipEntry{"content.xml", content},
+	)
+
+	doc, err := DecodeODT(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Guide\n\n"+
+		"Hello world.\nNext line\n\n"+
+		"## Install\n\n"+
+		"- Download\n  - Unpack\n\n"+
+		"| Flag | Use |\n| --- | --- |\n| merged |  |\n\n"+
+		"Boxed text\n\n"+
+		"After box\n", doc.Text)
+}
+
+func TestDecodeEPUB(t *testing.T) {
+	chapter := func(body string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Book</title></head><body>` + body + `</body></html>`
+	}
+
+	data := buildZip(t,
+		zipEntry{"mimetype", "application/epub+zip"},
+		zipEntry{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		zipEntry{"OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c2" href="text/chapter%202.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/chapter1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine><itemref idref="nav"/><itemref idref="c1"/><itemref idref="c2"/><itemref idref="missing"/></spine>
+</package>`},
+		zipEntry{"OEBPS/nav.xhtml", chapter(`<nav><ol><li>Chapter 1</li></ol></nav><p>Contents</p>`)},
+		zipEntry{"OEBPS/text/chapter1.xhtml", chapter(`<h1>Chapter 1</h1><p>It begins.</p><h2>Scene</h2><p>More.</p>`)},
+		zipEntry{"OEBPS/text/chapter 2.xhtml", chapter(`<h1>Chapter 2</h1><p>It ends.</p>`)},
+	)
+
+	doc, err := DecodeEPUB(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Chapter 1\n\nIt begins.\n\n## Scene\n\nMore.\n\n# Chapter 2\n\nIt ends.\n", doc.Text)
+}
+
+func TestDecodeInvalidArchives(t *testing.T) {
+	notZip := []byte("not a zip file")
+	emptyZip := buildZip(t, zipEntry{"readme.txt", "hello"})
+
+	tests := []struct {
+		name   string
+		decode DecodeFunc
+		data   []byte
+		err    string
+	}{
+		{name: "docx not zip", decode: DecodeDOCX, data: notZip, err: "failed to read docx"},
+		{name: "docx without document", decode: DecodeDOCX, data: emptyZip, err: "failed to read docx"},
+		{name: "odt without content", decode: DecodeODT, data: emptyZip, err: "failed to read odt"},
+		{name: "epub without container", decode: DecodeEPUB, data: emptyZip, err: "failed to read epub"},
+		{name: "broken xml", decode: DecodeODT, data: buildZip(t, zipEntry{"content.xml", "<a><b></a>"}), err: "invalid xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.decode(bytes.NewReader(tt.data))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
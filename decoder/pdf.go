@@ -0,0 +1,99 @@
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// glyphs further apart than this, in font sizes, are separate words
+	wordGap = 0.15
+	// baselines further apart than this, in font sizes, are separate lines
+	lineGap = 0.5
+	// lines further apart than this, in font sizes, are separate paragraphs
+	paragraphGap = 1.5
+	// width of glyphs of fonts without widths, in font sizes
+	defaultGlyphWidth = 0.5
+)
+
+// DecodePDF extracts the text of every page of a PDF document. Text is laid
+// out in content stream order, glyph positions decide where words, lines and
+// paragraphs break.
+func DecodePDF(r io.Reader) (doc *Document, err error) {
+	// the pdf package panics on malformed documents and content streams
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("failed to read pdf: %v", r)
+		}
+	}()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pdf: %w", err)
+	}
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pdf: %w", err)
+	}
+
+	doc = &Document{Pages: make([]string, 0, reader.NumPage())}
+	for i := 1; i <= reader.NumPage(); i++ {
+		doc.Pages = append(doc.Pages, pageText(reader.Page(i)))
+	}
+
+	return doc, nil
+}
+
+func pageText(page pdf.Page) string {
+	if page.V.IsNull() {
+		return ""
+	}
+
+	var out strings.Builder
+	var prev pdf.Text
+	var end float64
+	for _, glyph := range page.Content().Text {
+		if glyph.S == "\n" || glyph.S == "" {
+			continue
+		}
+
+		size := math.Abs(glyph.FontSize)
+		if size == 0 {
+			size = 1
+		}
+
+		if out.Len() > 0 {
+			dy := prev.Y - glyph.Y
+			switch {
+			case dy > paragraphGap*size || dy < -lineGap*size:
+				out.WriteString("\n\n")
+			case dy > lineGap*size:
+				out.WriteString("\n")
+			case glyph.X-end > wordGap*size && !strings.HasSuffix(out.String(), " ") && glyph.S != " ":
+				out.WriteString(" ")
+			}
+		}
+
+		out.WriteString(glyph.S)
+
+		width := glyph.W
+		if width == 0 {
+			width = defaultGlyphWidth * size
+		}
+		prev = glyph
+		end = glyph.X + width
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
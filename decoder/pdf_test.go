@@ -0,0 +1,42 @@
+package decoder
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePDF(t *testing.T) {
+	input, err := os.Open("../tests/example.pdf")
+	require.NoError(t, err)
+	defer input.Close()
+
+	doc, err := DecodePDF(input)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"Chopping PDFs\n\nPDF text is extracted page by page.\nLines of a paragraph are kept\non separate lines.",
+		"The second page starts here and\ncontinues on the next line.\n\nKerned words are split.",
+		"Café on the third page.",
+	}, doc.Pages)
+}
+
+func TestDecodeInvalidPDF(t *testing.T) {
+	_, err := DecodePDF(strings.NewReader("%PDF-1.4\nnot a pdf"))
+	assert.ErrorContains(t, err, "failed to read pdf")
+}
+
+func TestDecodeCorruptedPDF(t *testing.T) {
+	data, err := os.ReadFile("../tests/example.pdf")
+	require.NoError(t, err)
+
+	// the pdf package panics on a dictionary key which isn't a name
+	data = bytes.Replace(data, []byte("/Count 3"), []byte("(Count) 3"), 1)
+
+	_, err = DecodePDF(bytes.NewReader(data))
+	assert.EqualError(t, err, "failed to read pdf: unexpected non-name key string(Count) parsing dictionary")
+}
@@ -0,0 +1,106 @@
+package decoder
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+func openZip(r io.Reader) (*zip.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// readZipFile returns the content of the archive member name.
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// zipMembers returns the names of the zip members whose local headers are in
+// head.
+func zipMembers(head []byte) []string {
+	signature := []byte("PK\x03\x04")
+
+	var names []string
+	for len(head) >= 30 && bytes.HasPrefix(head, signature) {
+		nameLen := int(binary.LittleEndian.Uint16(head[26:]))
+		extraLen := int(binary.LittleEndian.Uint16(head[28:]))
+		if len(head) < 30+nameLen {
+			break
+		}
+		names = append(names, string(head[30:30+nameLen]))
+
+		data := min(30+nameLen+extraLen, len(head))
+		next := data + int(binary.LittleEndian.Uint32(head[18:]))
+		if binary.LittleEndian.Uint16(head[6:])&0x8 != 0 {
+			// the size is in a data descriptor after the data, the next
+			// member is found by its signature
+			i := bytes.Index(head[data:], signature)
+			if i < 0 {
+				break
+			}
+			next = data + i
+		}
+		if next > len(head) {
+			break
+		}
+		head = head[next:]
+	}
+	return names
+}
+
+// zipMimetype detects OpenDocument and EPUB files, they start with an
+// uncompressed mimetype member holding their media type.
+func zipMimetype(mimetype string) func(head []byte) bool {
+	return func(head []byte) bool {
+		if len(head) < 30 || !bytes.HasPrefix(head, []byte("PK\x03\x04")) || binary.LittleEndian.Uint16(head[8:]) != zip.Store {
+			return false
+		}
+
+		nameLen := int(binary.LittleEndian.Uint16(head[26:]))
+		extraLen := int(binary.LittleEndian.Uint16(head[28:]))
+		if len(head) < 30+nameLen+extraLen || string(head[30:30+nameLen]) != "mimetype" {
+			return false
+		}
+
+		return bytes.HasPrefix(head[30+nameLen+extraLen:], []byte(mimetype))
+	}
+}
+
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xmlTokens calls handle for every token of the XML document data.
+func xmlTokens(data []byte, handle func(d *xml.Decoder, token xml.Token) error) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid xml: %w", err)
+		}
+		if err := handle(d, token); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+const (
+	requestTimeout = 2 * time.Minute
+	maxBackoff     = 30 * time.Second
+)
+
+// Client calls the /embeddings endpoint of an OpenAI compatible API, like
+// OpenAI, Ollama, llama.cpp server or vLLM.
+type Client struct {
+	url     string
+	model   string
+	apiKey  string
+	http    *http.Client
+	retries int
+	// delay before the first retry, doubled on every further retry
+	backoff time.Duration
+	limiter *limiter
+}
+
+func NewClient(cfg *config.Config) *Client {
+	url := strings.TrimSuffix(cfg.EmbedURL, "/")
+	if !strings.HasSuffix(url, "/embeddings") {
+		url += "/embeddings"
+	}
+
+	return &Client{
+		url:     url,
+		model:   cfg.EmbedModel,
+		apiKey:  cfg.EmbedAPIKey,
+		http:    &http.Client{Timeout: requestTimeout},
+		retries: cfg.EmbedRetries,
+		backoff: 500 * time.Millisecond,
+		limiter: newLimiter(cfg.EmbedRate),
+	}
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type embeddingsResponse struct {
+	Data []embeddingData `json:"data"`
+}
+
+// statusError is an unsuccessful response, retryable errors are retried
+// after the delay asked by the server, if any.
+type statusError struct {
+	status     int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("embeddings request failed with status %d: %s", e.status, e.body)
+}
+
+func (e *statusError) retryable() bool {
+	return e.status == http.StatusTooManyRequests || e.status == http.StatusRequestTimeout || e.status >= 500
+}
+
+// Embed returns the embeddings of texts in their order. Rate limited, timed
+// out and failed requests are retried with exponential backoff.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingsRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		embeddings, err := c.request(ctx, body, len(texts))
+		if err == nil {
+			return embeddings, nil
+		}
+
+		var status *statusError
+		isStatus := errors.As(err, &status)
+		if attempt >= c.retries || ctx.Err() != nil || isStatus && !status.retryable() {
+			return nil, err
+		}
+
+		delay := c.delay(attempt)
+		if isStatus && status.retryAfter > 0 {
+			delay = status.retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// delay returns the backoff before the retry of attempt, it's doubled on every
+// attempt until it reaches maxBackoff.
+func (c *Client) delay(attempt int) time.Duration {
+	delay := min(c.backoff, maxBackoff)
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay = min(2*delay, maxBackoff)
+	}
+	return delay
+}
+
+func (c *Client) request(ctx context.Context, body []byte, count int) ([][]float32, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &statusError{
+			status:     resp.StatusCode,
+			body:       strings.TrimSpace(string(message)),
+			retryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	var response embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	if len(response.Data) != count {
+		return nil, fmt.Errorf("invalid embeddings response: got %d embeddings for %d inputs", len(response.Data), count)
+	}
+
+	embeddings := make([][]float32, count)
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= count || embeddings[data.Index] != nil {
+			return nil, fmt.Errorf("invalid embeddings response: unexpected index %d", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// retryAfter parses the delay of a Retry-After header given in seconds or as
+// an HTTP date.
+func retryAfter(header string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return min(time.Duration(seconds)*time.Second, maxBackoff)
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+	return min(date.Sub(now), maxBackoff)
+}
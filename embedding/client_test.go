@@ -0,0 +1,263 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubEmbeddings answers embeddings requests with the length of every input
+// as its embedding, in reverse order to check that indexes are honored.
+func stubEmbeddings(t *testing.T, w http.ResponseWriter, r *http.Request) []string {
+	t.Helper()
+
+	var request embeddingsRequest
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+	var response embeddingsResponse
+	for i := len(request.Input) - 1; i >= 0; i-- {
+		response.Data = append(response.Data, embeddingData{Index: i, Embedding: []float32{float32(len(request.Input[i]))}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(response))
+	return request.Input
+}
+
+func newTestClient(url string) *Client {
+	cfg := config.NewConfig()
+	cfg.EmbedURL = url
+	client := NewClient(cfg)
+	client.backoff = time.Millisecond
+	return client
+}
+
+func TestNewClientURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://api.openai.com/v1", expected: "https://api.openai.com/v1/embeddings"},
+		{url: "http://localhost:11434/v1/", expected: "http://localhost:11434/v1/embeddings"},
+		{url: "http://localhost:8080/v1/embeddings", expected: "http://localhost:8080/v1/embeddings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newTestClient(tt.url).url)
+		})
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		stubEmbeddings(t, w, r)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL + "/v1")
+	client.apiKey = "secret"
+
+	embeddings, err := client.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}, {3}}, embeddings)
+}
+
+func TestEmbedRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures []int
+		retries  int
+		wantErr  string
+		requests int32
+	}{
+		{
+			name:     "rate limited",
+			failures: []int{http.StatusTooManyRequests, http.StatusTooManyRequests},
+			retries:  2,
+			requests: 3,
+		},
+		{
+			name:     "server error",
+			failures: []int{http.StatusBadGateway},
+			retries:  2,
+			requests: 2,
+		},
+		{
+			name:     "retries exhausted",
+			failures: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			retries:  2,
+			wantErr:  "embeddings request failed with status 503: failure",
+			requests: 3,
+		},
+		{
+			name:     "bad request",
+			failures: []int{http.StatusBadRequest},
+			retries:  2,
+			wantErr:  "embeddings request failed with status 400: failure",
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				if int(n) <= len(tt.failures) {
+					w.Header().Set("Retry-After", "0")
+					http.Error(w, "failure", tt.failures[n-1])
+					return
+				}
+				stubEmbeddings(t, w, r)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			client.retries = tt.retries
+
+			embeddings, err := client.Embed(context.Background(), []string{"text"})
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, [][]float32{{4}}, embeddings)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.requests, requests.Load())
+		})
+	}
+}
+
+func TestEmbedRetryAfter(t *testing.T) {
+	var first time.Time
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		assert.GreaterOrEqual(t, time.Since(first), time.Second)
+		stubEmbeddings(t, w, r)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).Embed(context.Background(), []string{"text"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{name: "empty", header: ""},
+		{name: "seconds", header: "3", expected: 3 * time.Second},
+		{name: "negative seconds", header: "-3"},
+		{name: "seconds over max backoff", header: "3600", expected: maxBackoff},
+		{name: "date", header: "Sat, 01 Mar 2025 12:00:05 GMT", expected: 5 * time.Second},
+		{name: "past date", header: "Sat, 01 Mar 2025 11:59:00 GMT"},
+		{name: "date over max backoff", header: "Sun, 02 Mar 2025 12:00:00 GMT", expected: maxBackoff},
+		{name: "invalid", header: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, retryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	client := &Client{backoff: 500 * time.Millisecond}
+
+	assert.Equal(t, 500*time.Millisecond, client.delay(0))
+	assert.Equal(t, 4*time.Second, client.delay(3))
+	assert.Equal(t, maxBackoff, client.delay(6))
+	// the delay doesn't overflow with many retries
+	assert.Equal(t, maxBackoff, client.delay(35))
+	assert.Equal(t, maxBackoff, client.delay(1000))
+}
+
+func TestEmbedInvalidResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "not json",
+			response: "<html>",
+			wantErr:  "invalid embeddings response: invalid character '<' looking for beginning of value",
+		},
+		{
+			name:     "missing embeddings",
+			response: `{"data": [{"index": 0, "embedding": [1]}]}`,
+			wantErr:  "invalid embeddings response: got 1 embeddings for 2 inputs",
+		},
+		{
+			name:     "duplicate index",
+			response: `{"data": [{"index": 0, "embedding": [1]}, {"index": 0, "embedding": [2]}]}`,
+			wantErr:  "invalid embeddings response: unexpected index 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server.URL).Embed(context.Background(), []string{"a", "b"})
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEmbedRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stubEmbeddings(t, w, r)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.limiter = newLimiter(20)
+
+	start := time.Now()
+	for range 4 {
+		_, err := client.Embed(context.Background(), []string{"text"})
+		require.NoError(t, err)
+	}
+	// the first request is sent right away, the others 50ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestEmbedCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Embed(ctx, []string{"text"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
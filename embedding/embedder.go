@@ -0,0 +1,200 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+// Embedder adds embeddings to chunks, requesting them in batches with up to
+// workers requests in flight, shared by all of its sinks.
+type Embedder struct {
+	client    *Client
+	batchSize int
+	workers   int
+	// a slot for every request in flight
+	requests chan struct{}
+}
+
+func NewEmbedder(cfg *config.Config) *Embedder {
+	return &Embedder{
+		client:    NewClient(cfg),
+		batchSize: cfg.EmbedBatchSize,
+		workers:   cfg.EmbedWorkers,
+		requests:  make(chan struct{}, cfg.EmbedWorkers),
+	}
+}
+
+// batch is a batch of chunks being embedded, err is set when done is closed.
+type batch struct {
+	chunks []chopper.Chunk
+	starts []documentStart
+	done   chan struct{}
+	err    error
+}
+
+// documentStart is a document started before chunk index of a batch.
+type documentStart struct {
+	index int
+	name  string
+}
+
+// pipeline keeps the batches in flight in the order their chunks were
+// written.
+type pipeline struct {
+	embedder *Embedder
+	next     chopper.ChunkSink
+	embed    func(chunks []chopper.Chunk) error
+	cancel   context.CancelFunc
+	current  []chopper.Chunk
+	starts   []documentStart
+	pending  []*batch
+}
+
+// Sink returns a sink embedding chunks before they are written into next, in
+// the order they were written, document starts are passed on in order too.
+// flush embeds and writes the remaining chunks, after an error of the sink or
+// of flush requests in flight are cancelled.
+func (e *Embedder) Sink(ctx context.Context, next chopper.ChunkSink) (sink chopper.ChunkSink, flush func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &pipeline{
+		embedder: e,
+		next:     next,
+		cancel:   cancel,
+		embed: func(chunks []chopper.Chunk) error {
+			return e.embed(ctx, chunks)
+		},
+	}
+	return p, p.flush
+}
+
+func (p *pipeline) WriteChunk(chunk chopper.Chunk) error {
+	p.current = append(p.current, chunk)
+	if len(p.current) < p.embedder.batchSize {
+		return nil
+	}
+	return p.failed(p.dispatch())
+}
+
+func (p *pipeline) StartDocument(name string) error {
+	p.starts = append(p.starts, documentStart{index: len(p.current), name: name})
+	return nil
+}
+
+func (p *pipeline) flush() error {
+	defer p.cancel()
+	if len(p.current) > 0 || len(p.starts) > 0 {
+		if err := p.dispatch(); err != nil {
+			return err
+		}
+	}
+	for len(p.pending) > 0 {
+		if err := p.writeOldest(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatch starts embedding the current batch, once the workers are busy it
+// waits for the oldest batch first. Done batches are written without
+// waiting.
+func (p *pipeline) dispatch() error {
+	for len(p.pending) >= p.embedder.workers {
+		if err := p.writeOldest(); err != nil {
+			return err
+		}
+	}
+
+	b := &batch{chunks: p.current, starts: p.starts, done: make(chan struct{})}
+	p.current, p.starts = nil, nil
+	p.pending = append(p.pending, b)
+	go func() {
+		defer close(b.done)
+		if len(b.chunks) > 0 {
+			b.err = p.embed(b.chunks)
+		}
+	}()
+
+	for len(p.pending) > 0 && isDone(p.pending[0]) {
+		if err := p.writeOldest(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *pipeline) writeOldest() error {
+	b := p.pending[0]
+	<-b.done
+	p.pending = p.pending[1:]
+
+	if b.err != nil {
+		return b.err
+	}
+	starts := b.starts
+	var err error
+	for i, chunk := range b.chunks {
+		if starts, err = p.startDocuments(starts, i); err != nil {
+			return err
+		}
+		if err := p.next.WriteChunk(chunk); err != nil {
+			return err
+		}
+	}
+	_, err = p.startDocuments(starts, len(b.chunks))
+	return err
+}
+
+// startDocuments starts the documents started before chunk index and returns
+// the later ones.
+func (p *pipeline) startDocuments(starts []documentStart, index int) ([]documentStart, error) {
+	for len(starts) > 0 && starts[0].index == index {
+		if err := chopper.StartDocument(p.next, starts[0].name); err != nil {
+			return nil, err
+		}
+		starts = starts[1:]
+	}
+	return starts, nil
+}
+
+// failed cancels the requests in flight after an error.
+func (p *pipeline) failed(err error) error {
+	if err != nil {
+		p.cancel()
+	}
+	return err
+}
+
+func (e *Embedder) embed(ctx context.Context, chunks []chopper.Chunk) error {
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	select {
+	case e.requests <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	embeddings, err := e.client.Embed(ctx, texts)
+	<-e.requests
+	if err != nil {
+		return fmt.Errorf("failed to embed chunks: %w", err)
+	}
+	for i := range chunks {
+		chunks[i].Embedding = embeddings[i]
+	}
+	return nil
+}
+
+func isDone(b *batch) bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,175 @@
+package embedding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEmbedder(url string, batchSize, workers int) *Embedder {
+	cfg := config.NewConfig()
+	cfg.EmbedURL = url
+	cfg.EmbedBatchSize = batchSize
+	cfg.EmbedWorkers = workers
+
+	embedder := NewEmbedder(cfg)
+	embedder.client.backoff = time.Millisecond
+	return embedder
+}
+
+func TestEmbedderSink(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	var requests, inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := requests.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		// later batches finish first
+		time.Sleep(time.Duration(20-5*request) * time.Millisecond)
+
+		input := stubEmbeddings(t, w, r)
+		mu.Lock()
+		batches = append(batches, input)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	var written []chopper.Chunk
+	sink, flush := newTestEmbedder(server.URL, 3, 2).Sink(context.Background(), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		written = append(written, chunk)
+		return nil
+	}))
+
+	var expected []chopper.Chunk
+	for i := range 10 {
+		chunk := chopper.Chunk{Text: "chunk " + strings.Repeat("x", i)}
+		require.NoError(t, sink.WriteChunk(chunk))
+		chunk.Embedding = []float32{float32(len(chunk.Text))}
+		expected = append(expected, chunk)
+	}
+	require.NoError(t, flush())
+
+	assert.Equal(t, expected, written)
+	assert.Len(t, batches, 4)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestEmbedderSinksShareWorkers(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		stubEmbeddings(t, w, r)
+	}))
+	defer server.Close()
+
+	embedder := newTestEmbedder(server.URL, 1, 2)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink, flush := embedder.Sink(context.Background(), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+				return nil
+			}))
+			for range 4 {
+				assert.NoError(t, sink.WriteChunk(chopper.Chunk{Text: "text"}))
+			}
+			assert.NoError(t, flush())
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestEmbedderSinkEmpty(t *testing.T) {
+	sink, flush := newTestEmbedder("http://127.0.0.1:1", 3, 2).Sink(context.Background(), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		t.Fatal("unexpected chunk")
+		return nil
+	}))
+	assert.NotNil(t, sink)
+	assert.NoError(t, flush())
+}
+
+func TestEmbedderSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink, flush := newTestEmbedder(server.URL, 2, 1).Sink(context.Background(), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		t.Fatal("unexpected chunk")
+		return nil
+	}))
+
+	var err error
+	for i := 0; i < 4 && err == nil; i++ {
+		err = sink.WriteChunk(chopper.Chunk{Text: "text"})
+	}
+	if err == nil {
+		err = flush()
+	}
+	assert.EqualError(t, err, "failed to embed chunks: embeddings request failed with status 404: model not found")
+}
+
+type recordingSink struct {
+	events []string
+}
+
+func (s *recordingSink) WriteChunk(chunk chopper.Chunk) error {
+	s.events = append(s.events, chunk.Text)
+	return nil
+}
+
+func (s *recordingSink) StartDocument(name string) error {
+	s.events = append(s.events, "start "+name)
+	return nil
+}
+
+func TestEmbedderSinkDocuments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stubEmbeddings(t, w, r)
+	}))
+	defer server.Close()
+
+	next := &recordingSink{}
+	sink, flush := newTestEmbedder(server.URL, 2, 2).Sink(context.Background(), next)
+
+	require.NoError(t, chopper.StartDocument(sink, "a"))
+	for _, text := range []string{"a1", "a2", "a3"} {
+		require.NoError(t, sink.WriteChunk(chopper.Chunk{Text: text}))
+	}
+	require.NoError(t, chopper.StartDocument(sink, "empty"))
+	require.NoError(t, chopper.StartDocument(sink, "b"))
+	require.NoError(t, sink.WriteChunk(chopper.Chunk{Text: "b1"}))
+	require.NoError(t, chopper.StartDocument(sink, "last"))
+	require.NoError(t, flush())
+
+	assert.Equal(t, []string{"start a", "a1", "a2", "a3", "start empty", "start b", "b1", "start last"}, next.events)
+}
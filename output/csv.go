@@ -0,0 +1,126 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mirpo/chopdoc/chopper"
+)
+
+// chunkColumns are the CSV columns of chunk fields, in order, the columns of
+// metadata keys follow them.
+var chunkColumns = []string{"id", "source", "chunk", "sha256", "start_byte", "end_byte", "start_rune", "end_rune", "start_line", "end_line", "embedding"}
+
+// csvWriter writes chunks as CSV rows with a metadata.<key> column per
+// metadata key, nested metadata is flattened into dotted keys and lists, like
+// embeddings, are written as JSON. The columns are known after the last chunk
+// only, so rows are kept until Close.
+type csvWriter struct {
+	w       io.Writer
+	rows    []map[string]string
+	columns map[string]bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: w, columns: map[string]bool{"chunk": true}}
+}
+
+func (w *csvWriter) WriteChunk(chunk chopper.Chunk) error {
+	row := map[string]string{
+		"id":     chunk.ID,
+		"source": chunk.Source,
+		"chunk":  chunk.Text,
+		"sha256": chunk.SHA256,
+	}
+	if p := chunk.Position; p != nil {
+		for column, value := range map[string]int{
+			"start_byte": p.StartByte, "end_byte": p.EndByte,
+			"start_rune": p.StartRune, "end_rune": p.EndRune,
+			"start_line": p.StartLine, "end_line": p.EndLine,
+		} {
+			row[column] = strconv.Itoa(value)
+		}
+	}
+	if len(chunk.Embedding) > 0 {
+		embedding, err := encodeJSON(chunk.Embedding)
+		if err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		row["embedding"] = embedding
+	}
+	if err := flattenMetadata("metadata", chunk.Metadata, row); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+
+	for column, value := range row {
+		if value != "" {
+			w.columns[column] = true
+		}
+	}
+	w.rows = append(w.rows, row)
+
+	return nil
+}
+
+func (w *csvWriter) Close() error {
+	var header []string
+	for _, column := range chunkColumns {
+		if w.columns[column] {
+			header = append(header, column)
+		}
+	}
+	for _, column := range slices.Sorted(maps.Keys(w.columns)) {
+		if !slices.Contains(chunkColumns, column) {
+			header = append(header, column)
+		}
+	}
+
+	writer := csv.NewWriter(w.w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	record := make([]string, len(header))
+	for _, row := range w.rows {
+		for i, column := range header {
+			record[i] = row[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// flattenMetadata adds value to row under the column key, maps are flattened
+// into a column per key.
+func flattenMetadata(key string, value any, row map[string]string) error {
+	switch value := value.(type) {
+	case nil:
+	case map[string]any:
+		for k, v := range value {
+			if err := flattenMetadata(key+"."+k, v, row); err != nil {
+				return err
+			}
+		}
+	case string:
+		row[key] = value
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		row[key] = strings.TrimSpace(string(data))
+	}
+	return nil
+}
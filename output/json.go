@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mirpo/chopdoc/chopper"
+)
+
+// jsonWriter writes records as a single JSON array, a record per line.
+type jsonWriter struct {
+	w       io.Writer
+	record  recordFunc
+	buffer  bytes.Buffer
+	encoder *json.Encoder
+	count   int
+}
+
+func newJSONWriter(w io.Writer, record recordFunc) *jsonWriter {
+	writer := &jsonWriter{w: w, record: record}
+	writer.encoder = json.NewEncoder(&writer.buffer)
+	writer.encoder.SetEscapeHTML(false)
+
+	return writer
+}
+
+func (w *jsonWriter) WriteChunk(chunk chopper.Chunk) error {
+	record, err := w.record(chunk)
+	if err != nil {
+		return err
+	}
+
+	w.buffer.Reset()
+	if w.count == 0 {
+		w.buffer.WriteString("[\n")
+	} else {
+		w.buffer.WriteString(",\n")
+	}
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	w.count++
+
+	// the newline is written before the next chunk or the closing bracket
+	if _, err := w.w.Write(bytes.TrimSuffix(w.buffer.Bytes(), []byte("\n"))); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := io.WriteString(w.w, end); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
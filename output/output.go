@@ -0,0 +1,79 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+// Writer writes chunks to an output in one of the output formats. Close
+// finishes the output, like the closing bracket of a JSON array, but doesn't
+// close the underlying writer.
+type Writer interface {
+	chopper.ChunkSink
+	Close() error
+}
+
+// NewWriter returns the writer of the output format of cfg, JSONL when it's
+// empty. JSONL and JSON outputs write records of the schema of cfg.
+func NewWriter(cfg *config.Config, w io.Writer) (Writer, error) {
+	switch cfg.OutputFormat {
+	case "", config.FormatJSONL, config.FormatJSON:
+		record, err := newRecordFunc(cfg.Schema, cfg.RecordTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.OutputFormat == config.FormatJSON {
+			return newJSONWriter(w, record), nil
+		}
+		return newJSONLWriter(w, record), nil
+	case config.FormatCSV:
+		return newCSVWriter(w), nil
+	case config.FormatParquet:
+		return newParquetWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported output format: %s", cfg.OutputFormat)
+}
+
+// jsonlWriter writes every record as a single JSON line.
+type jsonlWriter struct {
+	encoder *json.Encoder
+	record  recordFunc
+}
+
+func newJSONLWriter(w io.Writer, record recordFunc) *jsonlWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return &jsonlWriter{encoder: encoder, record: record}
+}
+
+func (w *jsonlWriter) WriteChunk(chunk chopper.Chunk) error {
+	record, err := w.record(chunk)
+	if err != nil {
+		return err
+	}
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonlWriter) Close() error {
+	return nil
+}
+
+// encodeJSON encodes a value without escaping HTML, like JSONL chunks.
+func encodeJSON(value any) (string, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(data.Bytes(), []byte("\n"))), nil
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testChunks = []chopper.Chunk{
+	{
+		Source: "docs/a.md",
+		Text:   "# Intro\n<b>\"quoted\", text</b>",
+		Metadata: map[string]any{
+			"Header 1":   "Intro",
+			"breadcrumb": []any{"Intro"},
+			"front":      map[string]any{"draft": true, "tags": []any{"a"}},
+		},
+		Position: &chopper.Position{StartByte: 0, EndByte: 30, StartRune: 0, EndRune: 30, StartLine: 1, EndLine: 2},
+	},
+	{Source: "docs/b.txt", Text: "plain"},
+}
+
+func writeChunks(t *testing.T, format config.OutputFormat, chunks []chopper.Chunk) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	writer, err := NewWriter(&config.Config{OutputFormat: format}, &out)
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		require.NoError(t, writer.WriteChunk(chunk))
+	}
+	require.NoError(t, writer.Close())
+
+	return out.String()
+}
+
+func TestTextWriters(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   config.OutputFormat
+		chunks   []chopper.Chunk
+		expected string
+	}{
+		{
+			name:   "jsonl",
+			format: config.FormatJSONL,
+			chunks: testChunks[1:],
+			expected: `{"source":"docs/b.txt","chunk":"plain"}
+`,
+		},
+		{
+			name:   "json",
+			format: config.FormatJSON,
+			chunks: testChunks,
+			expected: `[
+{"source":"docs/a.md","chunk":"# Intro\n<b>\"quoted\", text</b>","metadata":{"Header 1":"Intro","breadcrumb":["Intro"],"front":{"draft":true,"tags":["a"]}},"position":{"start_byte":0,"end_byte":30,"start_rune":0,"end_rune":30,"start_line":1,"end_line":2}},
+{"source":"docs/b.txt","chunk":"plain"}
+]
+`,
+		},
+		{
+			name:     "empty json",
+			format:   config.FormatJSON,
+			expected: "[]\n",
+		},
+		{
+			name:   "csv",
+			format: config.FormatCSV,
+			chunks: testChunks,
+			expected: `source,chunk,start_byte,end_byte,start_rune,end_rune,start_line,end_line,metadata.Header 1,metadata.breadcrumb,metadata.front.draft,metadata.front.tags
+docs/a.md,"# Intro
+<b>""quoted"", text</b>",0,30,0,30,1,2,Intro,"[""Intro""]",true,"[""a""]"
+docs/b.txt,plain,,,,,,,,,,
+`,
+		},
+		{
+			name:   "csv with embedding",
+			format: config.FormatCSV,
+			chunks: []chopper.Chunk{{Source: "docs/b.txt", Text: "plain", Embedding: []float32{0.25, -1}}, {Source: "docs/c.txt", Text: "not embedded"}},
+			expected: `source,chunk,embedding
+docs/b.txt,plain,"[0.25,-1]"
+docs/c.txt,not embedded,
+`,
+		},
+		{
+			name:     "empty csv",
+			format:   config.FormatCSV,
+			expected: "chunk\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, writeChunks(t, tt.format, tt.chunks))
+		})
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	out := writeChunks(t, config.FormatParquet, append(testChunks, chopper.Chunk{Source: "docs/c.txt", Text: "embedded", Embedding: []float32{0.25, -1}}))
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader([]byte(out)), int64(len(out)))
+	require.NoError(t, err)
+
+	start, end, line := int64(0), int64(30), int64(1)
+	lastLine := int64(2)
+	assert.Equal(t, []parquetRow{
+		{
+			Source:    "docs/a.md",
+			Chunk:     "# Intro\n<b>\"quoted\", text</b>",
+			Metadata:  `{"Header 1":"Intro","breadcrumb":["Intro"],"front":{"draft":true,"tags":["a"]}}`,
+			StartByte: &start, EndByte: &end,
+			StartRune: &start, EndRune: &end,
+			StartLine: &line, EndLine: &lastLine,
+			Embedding: []float32{},
+		},
+		// chunks without embeddings have empty lists
+		{Source: "docs/b.txt", Chunk: "plain", Embedding: []float32{}},
+		{Source: "docs/c.txt", Chunk: "embedded", Embedding: []float32{0.25, -1}},
+	}, rows)
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter(&config.Config{OutputFormat: config.OutputFormat("xml")}, &bytes.Buffer{})
+	assert.EqualError(t, err, "unsupported output format: xml")
+}
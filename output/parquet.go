@@ -0,0 +1,77 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRow is the schema of Parquet outputs, metadata is kept as JSON since
+// its keys differ between chunks.
+type parquetRow struct {
+	ID        string    `parquet:"id,optional"`
+	Source    string    `parquet:"source,optional"`
+	Chunk     string    `parquet:"chunk"`
+	SHA256    string    `parquet:"sha256,optional"`
+	Metadata  string    `parquet:"metadata,optional,json"`
+	StartByte *int64    `parquet:"start_byte"`
+	EndByte   *int64    `parquet:"end_byte"`
+	StartRune *int64    `parquet:"start_rune"`
+	EndRune   *int64    `parquet:"end_rune"`
+	StartLine *int64    `parquet:"start_line"`
+	EndLine   *int64    `parquet:"end_line"`
+	Embedding []float32 `parquet:"embedding,list"`
+}
+
+type parquetWriter struct {
+	writer *parquet.GenericWriter[parquetRow]
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		writer: parquet.NewGenericWriter[parquetRow](w, parquet.Compression(&parquet.Snappy)),
+	}
+}
+
+func (w *parquetWriter) WriteChunk(chunk chopper.Chunk) error {
+	row := parquetRow{
+		ID:        chunk.ID,
+		Source:    chunk.Source,
+		Chunk:     chunk.Text,
+		SHA256:    chunk.SHA256,
+		Embedding: chunk.Embedding,
+	}
+
+	if len(chunk.Metadata) > 0 {
+		metadata, err := encodeJSON(chunk.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		row.Metadata = metadata
+	}
+
+	if p := chunk.Position; p != nil {
+		row.StartByte, row.EndByte = int64Ptr(p.StartByte), int64Ptr(p.EndByte)
+		row.StartRune, row.EndRune = int64Ptr(p.StartRune), int64Ptr(p.EndRune)
+		row.StartLine, row.EndLine = int64Ptr(p.StartLine), int64Ptr(p.EndLine)
+	}
+
+	if _, err := w.writer.Write([]parquetRow{row}); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+func int64Ptr(v int) *int64 {
+	n := int64(v)
+	return &n
+}
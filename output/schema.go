@@ -0,0 +1,120 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"text/template"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+// recordFunc converts a chunk into the record written for it in an output
+// schema.
+type recordFunc func(chunk chopper.Chunk) (any, error)
+
+type langChainRecord struct {
+	ID          string         `json:"id,omitempty"`
+	PageContent string         `json:"page_content"`
+	Metadata    map[string]any `json:"metadata"`
+	Embedding   []float32      `json:"embedding,omitempty"`
+}
+
+type llamaIndexRecord struct {
+	ID           string         `json:"id_,omitempty"`
+	Text         string         `json:"text"`
+	Metadata     map[string]any `json:"metadata"`
+	StartCharIdx *int           `json:"start_char_idx,omitempty"`
+	EndCharIdx   *int           `json:"end_char_idx,omitempty"`
+	Embedding    []float32      `json:"embedding,omitempty"`
+}
+
+type haystackRecord struct {
+	ID        string         `json:"id,omitempty"`
+	Content   string         `json:"content"`
+	Meta      map[string]any `json:"meta"`
+	Embedding []float32      `json:"embedding,omitempty"`
+}
+
+// newRecordFunc returns the records of schema, custom records are rendered
+// by the text/template recordTemplate.
+func newRecordFunc(schema config.Schema, recordTemplate string) (recordFunc, error) {
+	switch schema {
+	case "", config.SchemaChopdoc:
+		return func(chunk chopper.Chunk) (any, error) {
+			return chunk, nil
+		}, nil
+	case config.SchemaLangChain:
+		return func(chunk chopper.Chunk) (any, error) {
+			metadata := schemaMetadata(chunk)
+			if chunk.Position != nil {
+				addMissing(metadata, "start_index", chunk.Position.StartRune)
+			}
+			return langChainRecord{ID: chunk.ID, PageContent: chunk.Text, Metadata: metadata, Embedding: chunk.Embedding}, nil
+		}, nil
+	case config.SchemaLlamaIndex:
+		return func(chunk chopper.Chunk) (any, error) {
+			record := llamaIndexRecord{ID: chunk.ID, Text: chunk.Text, Metadata: schemaMetadata(chunk), Embedding: chunk.Embedding}
+			if chunk.Position != nil {
+				record.StartCharIdx, record.EndCharIdx = &chunk.Position.StartRune, &chunk.Position.EndRune
+			}
+			return record, nil
+		}, nil
+	case config.SchemaHaystack:
+		return func(chunk chopper.Chunk) (any, error) {
+			meta := schemaMetadata(chunk)
+			if chunk.Position != nil {
+				addMissing(meta, "split_idx_start", chunk.Position.StartRune)
+			}
+			return haystackRecord{ID: chunk.ID, Content: chunk.Text, Meta: meta, Embedding: chunk.Embedding}, nil
+		}, nil
+	case config.SchemaCustom:
+		return newTemplateRecordFunc(recordTemplate)
+	}
+	return nil, fmt.Errorf("unsupported schema: %s", schema)
+}
+
+// schemaMetadata returns the metadata of chunk with its source and hash,
+// unless the metadata has keys of the same name.
+func schemaMetadata(chunk chopper.Chunk) map[string]any {
+	metadata := make(map[string]any, len(chunk.Metadata)+2)
+	maps.Copy(metadata, chunk.Metadata)
+	if chunk.Source != "" {
+		addMissing(metadata, "source", chunk.Source)
+	}
+	if chunk.SHA256 != "" {
+		addMissing(metadata, "sha256", chunk.SHA256)
+	}
+	return metadata
+}
+
+func addMissing(metadata map[string]any, key string, value any) {
+	if _, ok := metadata[key]; !ok {
+		metadata[key] = value
+	}
+}
+
+// newTemplateRecordFunc renders chunks with a text/template, the json function
+// encodes a value as JSON. The rendered record must be JSON, it's compacted
+// to a single line.
+func newTemplateRecordFunc(recordTemplate string) (recordFunc, error) {
+	tmpl, err := template.New("record").Funcs(template.FuncMap{"json": encodeJSON}).Parse(recordTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid record template: %w", err)
+	}
+
+	return func(chunk chopper.Chunk) (any, error) {
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, chunk); err != nil {
+			return nil, fmt.Errorf("failed to render record template: %w", err)
+		}
+
+		var record bytes.Buffer
+		if err := json.Compact(&record, rendered.Bytes()); err != nil {
+			return nil, fmt.Errorf("record template must render JSON, got %q: %w", rendered.String(), err)
+		}
+		return json.RawMessage(record.Bytes()), nil
+	}, nil
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemas(t *testing.T) {
+	chunk := chopper.Chunk{
+		ID:       "docs/a.md#0",
+		Source:   "docs/a.md",
+		Text:     "<b>intro</b>",
+		SHA256:   "abc",
+		Metadata: map[string]any{"Header 1": "Intro", "url": "https://example.com/a"},
+		Position: &chopper.Position{StartByte: 2, EndByte: 14, StartRune: 2, EndRune: 14, StartLine: 1, EndLine: 1},
+	}
+
+	tests := []struct {
+		name     string
+		schema   config.Schema
+		template string
+		chunk    chopper.Chunk
+		expected string
+	}{
+		{
+			name:     "chopdoc",
+			schema:   config.SchemaChopdoc,
+			chunk:    chopper.Chunk{Source: "docs/a.md", Text: "<b>intro</b>"},
+			expected: `{"source":"docs/a.md","chunk":"<b>intro</b>"}`,
+		},
+		{
+			name:     "langchain",
+			schema:   config.SchemaLangChain,
+			chunk:    chunk,
+			expected: `{"id":"docs/a.md#0","page_content":"<b>intro</b>","metadata":{"Header 1":"Intro","sha256":"abc","source":"docs/a.md","start_index":2,"url":"https://example.com/a"}}`,
+		},
+		{
+			name:     "langchain without optional fields",
+			schema:   config.SchemaLangChain,
+			chunk:    chopper.Chunk{Text: "piped"},
+			expected: `{"page_content":"piped","metadata":{}}`,
+		},
+		{
+			name:     "llamaindex",
+			schema:   config.SchemaLlamaIndex,
+			chunk:    chunk,
+			expected: `{"id_":"docs/a.md#0","text":"<b>intro</b>","metadata":{"Header 1":"Intro","sha256":"abc","source":"docs/a.md","url":"https://example.com/a"},"start_char_idx":2,"end_char_idx":14}`,
+		},
+		{
+			name:     "haystack",
+			schema:   config.SchemaHaystack,
+			chunk:    chunk,
+			expected: `{"id":"docs/a.md#0","content":"<b>intro</b>","meta":{"Header 1":"Intro","sha256":"abc","source":"docs/a.md","split_idx_start":2,"url":"https://example.com/a"}}`,
+		},
+		{
+			name:     "haystack with embedding",
+			schema:   config.SchemaHaystack,
+			chunk:    chopper.Chunk{Text: "embedded", Embedding: []float32{0.25, -1}},
+			expected: `{"content":"embedded","meta":{},"embedding":[0.25,-1]}`,
+		},
+		{
+			name:   "metadata keys win",
+			schema: config.SchemaHaystack,
+			chunk: chopper.Chunk{
+				Source:   "records.jsonl:1",
+				Text:     "text",
+				Metadata: map[string]any{"source": "https://example.com/a"},
+			},
+			expected: `{"content":"text","meta":{"source":"https://example.com/a"}}`,
+		},
+		{
+			name:   "custom",
+			schema: config.SchemaCustom,
+			template: `{
+  "text": {{json .Text}},
+  "id": {{json .ID}},
+  "url": {{json .Metadata.url}},
+  "missing": {{json .Metadata.missing}}{{with .Position}},
+  "line": {{.StartLine}}{{end}}
+}`,
+			chunk:    chunk,
+			expected: `{"text":"<b>intro</b>","id":"docs/a.md#0","url":"https://example.com/a","missing":null,"line":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, format := range []config.OutputFormat{config.FormatJSONL, config.FormatJSON} {
+				var out bytes.Buffer
+				writer, err := NewWriter(&config.Config{OutputFormat: format, Schema: tt.schema, RecordTemplate: tt.template}, &out)
+				require.NoError(t, err)
+				require.NoError(t, writer.WriteChunk(tt.chunk))
+				require.NoError(t, writer.Close())
+
+				expected := tt.expected + "\n"
+				if format == config.FormatJSON {
+					expected = "[\n" + tt.expected + "\n]\n"
+				}
+				assert.Equal(t, expected, out.String(), format)
+			}
+		})
+	}
+}
+
+func TestCustomSchemaErrors(t *testing.T) {
+	_, err := NewWriter(&config.Config{Schema: config.SchemaCustom, RecordTemplate: "{{.Text"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "invalid record template")
+
+	writer, err := NewWriter(&config.Config{Schema: config.SchemaCustom, RecordTemplate: "{{.Text}}"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.WriteChunk(chopper.Chunk{Text: "plain text"}), `record template must render JSON, got "plain text"`)
+
+	writer, err = NewWriter(&config.Config{Schema: config.SchemaCustom, RecordTemplate: "{{.Unknown}}"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.WriteChunk(chopper.Chunk{Text: "text"}), "failed to render record template")
+}
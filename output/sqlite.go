@@ -0,0 +1,202 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/mirpo/chopdoc/chopper"
+
+	// pure Go driver, chopdoc builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema keeps the chunks_fts full text index in sync with the chunks
+// table by triggers.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	rowid INTEGER PRIMARY KEY,
+	id TEXT,
+	document TEXT,
+	source TEXT NOT NULL,
+	ordinal INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	sha256 TEXT,
+	metadata TEXT,
+	start_byte INTEGER,
+	end_byte INTEGER,
+	start_rune INTEGER,
+	end_rune INTEGER,
+	start_line INTEGER,
+	end_line INTEGER,
+	embedding BLOB
+);
+CREATE INDEX IF NOT EXISTS chunks_source ON chunks (source, ordinal);
+CREATE INDEX IF NOT EXISTS chunks_document ON chunks (document);
+CREATE VIRTUAL TABLE IF NOT EXISTS chunks_fts USING fts5 (text, content = 'chunks', content_rowid = 'rowid');
+CREATE TRIGGER IF NOT EXISTS chunks_insert AFTER INSERT ON chunks BEGIN
+	INSERT INTO chunks_fts (rowid, text) VALUES (new.rowid, new.text);
+END;
+CREATE TRIGGER IF NOT EXISTS chunks_delete AFTER DELETE ON chunks BEGIN
+	INSERT INTO chunks_fts (chunks_fts, rowid, text) VALUES ('delete', old.rowid, old.text);
+END;
+`
+
+// SQLiteWriter writes chunks into the chunks table of a SQLite database. The
+// rows of an input document, like a JSONL file of many record sources, are
+// replaced in a transaction of their own when the document starts, so
+// re-running on the same inputs doesn't duplicate rows or keep stale ones.
+type SQLiteWriter struct {
+	db       *sql.DB
+	tx       *sql.Tx
+	insert   *sql.Stmt
+	document string
+	// documents are started by StartDocument, otherwise every source is a
+	// document of its own
+	documents bool
+	started   map[string]bool
+	// ordinal of the next chunk of every source written so far
+	ordinals map[string]int
+}
+
+// OpenSQLite opens or creates the database at path and its tables.
+func OpenSQLite(path string) (*SQLiteWriter, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite output: %w", err)
+	}
+	// transactions need a single connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite tables: %w", err)
+	}
+
+	return &SQLiteWriter{db: db, started: make(map[string]bool), ordinals: make(map[string]int)}, nil
+}
+
+// StartDocument commits the rows of the previous document and replaces the
+// rows of the document name with the chunks written next.
+func (w *SQLiteWriter) StartDocument(name string) error {
+	w.documents = true
+	return w.startDocument(name)
+}
+
+func (w *SQLiteWriter) WriteChunk(chunk chopper.Chunk) error {
+	if !w.documents && (w.tx == nil || chunk.Source != w.document) {
+		if err := w.startDocument(chunk.Source); err != nil {
+			return err
+		}
+	}
+
+	var metadata any
+	if len(chunk.Metadata) > 0 {
+		data, err := encodeJSON(chunk.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		metadata = data
+	}
+
+	position := make([]any, 6)
+	if p := chunk.Position; p != nil {
+		position = []any{p.StartByte, p.EndByte, p.StartRune, p.EndRune, p.StartLine, p.EndLine}
+	}
+
+	ordinal := w.ordinals[chunk.Source]
+	w.ordinals[chunk.Source]++
+
+	args := append([]any{nullString(chunk.ID), w.document, chunk.Source, ordinal, chunk.Text, nullString(chunk.SHA256), metadata}, position...)
+	args = append(args, embeddingBlob(chunk.Embedding))
+	if _, err := w.insert.Exec(args...); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return nil
+}
+
+// startDocument commits the rows of the previous document and deletes the
+// rows of document, unless it was started before in this run.
+func (w *SQLiteWriter) startDocument(document string) error {
+	if err := w.commit(); err != nil {
+		return err
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start sqlite transaction: %w", err)
+	}
+	w.tx, w.document = tx, document
+
+	if !w.started[document] {
+		w.started[document] = true
+		if _, err := tx.Exec(`DELETE FROM chunks WHERE document = ?`, document); err != nil {
+			return fmt.Errorf("failed to delete chunks of %s: %w", document, err)
+		}
+	}
+
+	w.insert, err = tx.Prepare(`INSERT INTO chunks (id, document, source, ordinal, text, sha256, metadata,
+		start_byte, end_byte, start_rune, end_rune, start_line, end_line, embedding)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare sqlite insert: %w", err)
+	}
+
+	return nil
+}
+
+func (w *SQLiteWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+
+	tx := w.tx
+	w.tx = nil
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chunks of %s: %w", w.document, err)
+	}
+	return nil
+}
+
+// Close commits the rows of the last document and closes the database.
+func (w *SQLiteWriter) Close() error {
+	if err := w.commit(); err != nil {
+		w.db.Close()
+		return err
+	}
+	if err := w.db.Close(); err != nil {
+		return fmt.Errorf("failed to close sqlite output: %w", err)
+	}
+	return nil
+}
+
+// Rollback drops the rows of the document being written, rows of documents
+// written before are kept, and closes the database.
+func (w *SQLiteWriter) Rollback() error {
+	if w.tx != nil {
+		w.tx.Rollback()
+		w.tx = nil
+	}
+	return w.db.Close()
+}
+
+// embeddingBlob encodes an embedding as little endian float32 values, the
+// vector format of sqlite-vec.
+func embeddingBlob(embedding []float32) any {
+	if len(embedding) == 0 {
+		return nil
+	}
+	blob := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSQLite(t *testing.T, path string, chunks ...chopper.Chunk) {
+	t.Helper()
+
+	writer, err := OpenSQLite(path)
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		require.NoError(t, writer.WriteChunk(chunk))
+	}
+	require.NoError(t, writer.Close())
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string, args ...any) []string {
+	t.Helper()
+
+	rows, err := db.Query(query, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		require.NoError(t, rows.Scan(&value))
+		values = append(values, value)
+	}
+	require.NoError(t, rows.Err())
+
+	return values
+}
+
+func TestSQLiteWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.sqlite")
+
+	writeSQLite(t, path, append(testChunks,
+		chopper.Chunk{Source: "docs/a.md", Text: "second chunk of a"},
+		chopper.Chunk{ID: "c#0", Source: "docs/c.txt", Text: "the quick brown fox", SHA256: "hash"},
+	)...)
+	// a re-run replaces the rows of the sources it writes
+	writeSQLite(t, path, chopper.Chunk{Source: "docs/a.md", Text: "rewritten a"})
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []string{
+		"docs/a.md|0|rewritten a",
+		"docs/b.txt|0|plain",
+		"docs/c.txt|0|the quick brown fox",
+	}, queryStrings(t, db, `SELECT source || '|' || ordinal || '|' || text FROM chunks ORDER BY source, ordinal`))
+
+	assert.Equal(t, []string{"c#0|hash|"}, queryStrings(t, db, `SELECT id || '|' || sha256 || '|' || COALESCE(metadata, '') FROM chunks WHERE id IS NOT NULL`))
+
+	assert.Equal(t, []string{"docs/c.txt"}, queryStrings(t, db, `SELECT chunks.source FROM chunks_fts JOIN chunks ON chunks.rowid = chunks_fts.rowid WHERE chunks_fts MATCH ?`, "brown"))
+	assert.Empty(t, queryStrings(t, db, `SELECT rowid FROM chunks_fts WHERE chunks_fts MATCH ?`, "intro"))
+	assert.Equal(t, []string{"docs/a.md"}, queryStrings(t, db, `SELECT chunks.source FROM chunks_fts JOIN chunks ON chunks.rowid = chunks_fts.rowid WHERE chunks_fts MATCH ?`, "rewritten"))
+}
+
+func TestSQLiteWriterMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.sqlite")
+	writeSQLite(t, path, testChunks[0])
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []string{`Intro|["Intro"]|0|30|1|2`}, queryStrings(t, db, `SELECT json_extract(metadata, '$."Header 1"') || '|' || json_extract(metadata, '$.breadcrumb') || '|' ||
+		start_byte || '|' || end_byte || '|' || start_line || '|' || end_line FROM chunks`))
+}
+
+func TestSQLiteWriterRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.sqlite")
+	writeSQLite(t, path, chopper.Chunk{Source: "a.txt", Text: "old a"}, chopper.Chunk{Source: "b.txt", Text: "old b"})
+
+	writer, err := OpenSQLite(path)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteChunk(chopper.Chunk{Source: "a.txt", Text: "new a"}))
+	require.NoError(t, writer.WriteChunk(chopper.Chunk{Source: "b.txt", Text: "new b"}))
+	require.NoError(t, writer.Rollback())
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// sources written before the failed one are kept
+	assert.Equal(t, []string{"new a", "old b"}, queryStrings(t, db, `SELECT text FROM chunks ORDER BY source`))
+}
+
+func TestSQLiteWriterDocuments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.sqlite")
+
+	write := func(documents map[string][]string) {
+		writer, err := OpenSQLite(path)
+		require.NoError(t, err)
+		for _, document := range []string{"r.jsonl", "b.txt"} {
+			sources, ok := documents[document]
+			if !ok {
+				continue
+			}
+			require.NoError(t, writer.StartDocument(document))
+			for _, source := range sources {
+				require.NoError(t, writer.WriteChunk(chopper.Chunk{Source: source, Text: "text of " + source}))
+			}
+		}
+		require.NoError(t, writer.Close())
+	}
+
+	write(map[string][]string{"r.jsonl": {"r.jsonl:1", "r.jsonl:2", "r.jsonl:3"}, "b.txt": {"b.txt"}})
+	// the shorter document replaces all its rows, a document without chunks
+	// removes them
+	write(map[string][]string{"r.jsonl": {"r.jsonl:1"}, "b.txt": nil})
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []string{"r.jsonl|r.jsonl:1"}, queryStrings(t, db, `SELECT document || '|' || source FROM chunks ORDER BY source`))
+}
+
+func TestSQLiteWriterEmbedding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.sqlite")
+	writeSQLite(t, path, chopper.Chunk{Source: "docs/a.txt", Text: "embedded", Embedding: []float32{1, -2}}, chopper.Chunk{Source: "docs/b.txt", Text: "plain"})
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []string{"docs/a.txt|0000803f000000c0", "docs/b.txt|"}, queryStrings(t, db, `SELECT source || '|' || COALESCE(lower(hex(embedding)), '') FROM chunks ORDER BY source`))
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+func isZip(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("PK\x05\x06"))
+}
+
+// isTar recognizes POSIX and GNU tar archives by the ustar magic, older ones
+// by the extension.
+func isTar(name string, head []byte) bool {
+	if len(head) >= 262 && string(head[257:262]) == "ustar" {
+		return true
+	}
+	return strings.EqualFold(path.Ext(name), ".tar")
+}
+
+// chopZip chops the members of a zip archive. Zip needs random access, so
+// inputs other than regular files are read into memory.
+func (r *Runner) chopZip(ctx context.Context, cfg *config.Config, input io.Reader, reader *bufio.Reader, sink chopper.ChunkSink) error {
+	archive, err := zipReader(input, reader)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, member := range archive.File {
+		if !member.Mode().IsRegular() || hiddenMember(member.Name) {
+			continue
+		}
+
+		f, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read zip member %s: %w", member.Name, err)
+		}
+		err = r.chopMember(ctx, cfg, member.Name, f, sink)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func zipReader(input io.Reader, reader io.Reader) (*zip.Reader, error) {
+	if file, ok := input.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+			return zip.NewReader(file, info.Size())
+		}
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// chopTar chops the members of a tar archive as they are read.
+func (r *Runner) chopTar(ctx context.Context, cfg *config.Config, reader io.Reader, sink chopper.ChunkSink) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		if !header.FileInfo().Mode().IsRegular() || hiddenMember(header.Name) {
+			continue
+		}
+
+		if err := r.chopMember(ctx, cfg, header.Name, archive, sink); err != nil {
+			return err
+		}
+	}
+}
+
+// chopMember chops an archive member as a document of its own, its source
+// is the member path below the archive source.
+func (r *Runner) chopMember(ctx context.Context, cfg *config.Config, member string, input io.Reader, sink chopper.ChunkSink) error {
+	member = strings.TrimPrefix(path.Clean("/"+member), "/")
+
+	memberCfg := *cfg
+	memberCfg.InputFile = member
+	if cfg.InputFile != "" {
+		memberCfg.InputFile = cfg.InputFile + "/" + member
+	}
+
+	if err := r.chopStream(ctx, &memberCfg, member, input, sink); err != nil {
+		return fmt.Errorf("%s: %w", member, err)
+	}
+	return nil
+}
+
+// hiddenMember reports whether an archive member is hidden like files
+// skipped in directories, including macOS resource forks.
+func hiddenMember(name string) bool {
+	for _, part := range strings.Split(path.Clean(name), "/") {
+		if strings.HasPrefix(part, ".") && part != "." || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// bzip2Text is "bzip2 text" compressed with bzip2 -9, the standard library
+// has no bzip2 writer
+const bzip2Text = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x62\x27\xdf\xd6\x00\x00\x01\x19\x80\x40\x00\x10\x00\x12\x20\x44\x50\x20\x00\x22\x01\x93\xd4\x20\xc9\x88\xa4\xe0\xe1\x87\x8b\xb9\x22\x9c\x28\x48\x31\x13\xef\xeb\x00"
+
+func gzipText(t *testing.T, text string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(text))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func xzText(t *testing.T, text string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(text))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func zstdText(t *testing.T, text string) string {
+	t.Helper()
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	return string(encoder.EncodeAll([]byte(text), nil))
+}
+
+func zipArchive(t *testing.T, members ...[2]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, member := range members {
+		f, err := w.Create(member[0])
+		require.NoError(t, err)
+		_, err = f.Write([]byte(member[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func tarArchive(t *testing.T, members ...[2]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "./corpus/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, member := range members {
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: member[0], Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(member[1]))}))
+		_, err := w.Write([]byte(member[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestCompressedInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.md.gz":   gzipText(t, "# Title\ngzip text\n"),
+		"docs/b.txt.bz2": bzip2Text,
+		"docs/c.txt.xz":  xzText(t, "xz text"),
+		"docs/d.txt.zst": zstdText(t, "zstd text"),
+		"docs/e.txt":     gzipText(t, "sniffed gzip text"),
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Method = config.Auto
+	cfg.AddMetadata = true
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{
+		{Source: "docs/a.md.gz", Text: "# Title\ngzip text\n", Metadata: map[string]any{
+			"Header 1": "Title", "breadcrumb": []any{"Title"}, "h_path": "Title",
+		}},
+		{Source: "docs/b.txt.bz2", Text: "bzip2 text"},
+		{Source: "docs/c.txt.xz", Text: "xz text"},
+		{Source: "docs/d.txt.zst", Text: "zstd text"},
+		{Source: "docs/e.txt", Text: "sniffed gzip text"},
+	}, readChunks(t, "out.jsonl"))
+}
+
+func TestArchiveInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dumps/corpus.zip": zipArchive(t,
+			[2]string{"docs/", ""},
+			[2]string{"docs/a.txt", "zip member"},
+			[2]string{"docs/.hidden", "hidden"},
+			[2]string{"__MACOSX/docs/._a.txt", "resource fork"},
+			[2]string{"docs/b.txt.gz", gzipText(t, "compressed zip member")},
+			[2]string{"nested.tar", tarArchive(t, [2]string{"inner.txt", "nested member"})},
+		),
+		"dumps/corpus.tar.gz": gzipText(t, tarArchive(t,
+			[2]string{"./corpus/one.txt", "tar member one"},
+			[2]string{"corpus/two.txt", "tar member two"},
+		)),
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"dumps/corpus.zip", "dumps/corpus.tar.gz"}
+	cfg.OutputFile = "out.jsonl"
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{
+		{Source: "dumps/corpus.zip/docs/a.txt", Text: "zip member"},
+		{Source: "dumps/corpus.zip/docs/b.txt.gz", Text: "compressed zip member"},
+		{Source: "dumps/corpus.zip/nested.tar/inner.txt", Text: "nested member"},
+		{Source: "dumps/corpus.tar.gz/corpus/one.txt", Text: "tar member one"},
+		{Source: "dumps/corpus.tar.gz/corpus/two.txt", Text: "tar member two"},
+	}, readChunks(t, "out.jsonl"))
+}
+
+func TestPipedArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []chopper.Chunk
+	}{
+		{
+			name:     "gzip",
+			input:    gzipText(t, "piped text"),
+			expected: []chopper.Chunk{{Text: "piped text"}},
+		},
+		{
+			name:     "zip",
+			input:    zipArchive(t, [2]string{"a.txt", "first"}, [2]string{"b.txt", "second"}),
+			expected: []chopper.Chunk{{Source: "a.txt", Text: "first"}, {Source: "b.txt", Text: "second"}},
+		},
+		{
+			name:     "tar.xz",
+			input:    xzText(t, tarArchive(t, [2]string{"a.txt", "tarred"})),
+			expected: []chopper.Chunk{{Source: "a.txt", Text: "tarred"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Piped = true
+			require.NoError(t, cfg.Validate())
+
+			var out bytes.Buffer
+			require.NoError(t, NewRunner(cfg).chop(context.Background(), cfg, bytes.NewReader([]byte(tt.input)), chopper.NewJSONLSink(&out)))
+			assert.Equal(t, tt.expected, parseChunks(t, out.Bytes()))
+		})
+	}
+}
+
+func TestCorruptArchive(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.InputFile = "broken.zip"
+
+	err := NewRunner(cfg).chop(context.Background(), cfg, bytes.NewReader([]byte("PK\x03\x04broken")), chopper.NewJSONLSink(io.Discard))
+	assert.ErrorContains(t, err, "failed to read zip archive")
+
+	err = NewRunner(cfg).chop(context.Background(), cfg, bytes.NewReader([]byte("\x1f\x8bbroken")), chopper.NewJSONLSink(io.Discard))
+	assert.ErrorContains(t, err, "failed to decompress gzip input")
+}
+
+func TestDecompressedName(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression string
+		expected    string
+	}{
+		{name: "notes.md.gz", compression: "gzip", expected: "notes.md"},
+		{name: "corpus.tgz", compression: "gzip", expected: "corpus.tar"},
+		{name: "corpus.TAR.XZ", compression: "xz", expected: "corpus.TAR"},
+		{name: "dump.tzst", compression: "zstd", expected: "dump.tar"},
+		{name: "data.bin", compression: "bzip2", expected: "data.bin"},
+		{name: "", compression: "gzip", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range compressions {
+				if c.name == tt.compression {
+					assert.Equal(t, tt.expected, c.decompressedName(tt.name))
+				}
+			}
+		})
+	}
+}
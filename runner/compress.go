@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mirpo/chopdoc/decoder"
+	"github.com/ulikunitz/xz"
+)
+
+// compression is a compressed stream format, recognized by its magic bytes.
+type compression struct {
+	name  string
+	sniff func(head []byte) bool
+	open  func(r io.Reader) (io.ReadCloser, error)
+	// extensions and what they are replaced with after decompression
+	extensions map[string]string
+}
+
+var compressions = []compression{
+	{
+		name:       "gzip",
+		sniff:      decoder.HasMagic("\x1f\x8b"),
+		extensions: map[string]string{".gz": "", ".tgz": ".tar"},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name: "bzip2",
+		sniff: func(head []byte) bool {
+			// the magic is followed by the block size, 1-9
+			return len(head) > 3 && bytes.HasPrefix(head, []byte("BZh")) && head[3] >= '1' && head[3] <= '9'
+		},
+		extensions: map[string]string{".bz2": "", ".tbz2": ".tar"},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		name:       "xz",
+		sniff:      decoder.HasMagic("\xfd7zXZ\x00"),
+		extensions: map[string]string{".xz": "", ".txz": ".tar"},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			reader, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(reader), nil
+		},
+	},
+	{
+		name:       "zstd",
+		sniff:      decoder.HasMagic("\x28\xb5\x2f\xfd"),
+		extensions: map[string]string{".zst": "", ".tzst": ".tar"},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			reader, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return reader.IOReadCloser(), nil
+		},
+	},
+}
+
+// detectCompression returns the compression of a stream starting with head.
+func detectCompression(head []byte) *compression {
+	for i := range compressions {
+		if compressions[i].sniff(head) {
+			return &compressions[i]
+		}
+	}
+	return nil
+}
+
+// decompressedName strips the compression extension from name, so the
+// document is recognized by its own extension, e.g. notes.md.gz is notes.md
+// and corpus.tgz is corpus.tar.
+func (c *compression) decompressedName(name string) string {
+	ext := path.Ext(name)
+	replacement, ok := c.extensions[strings.ToLower(ext)]
+	if !ok {
+		return name
+	}
+	return strings.TrimSuffix(name, ext) + replacement
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// gitIgnore holds the .gitignore rules found while walking a directory tree,
+// rules of a .gitignore apply to paths below its directory.
+type gitIgnore struct {
+	rules map[string][]pathPattern
+}
+
+func newGitIgnore() *gitIgnore {
+	return &gitIgnore{rules: make(map[string][]pathPattern)}
+}
+
+// load reads the .gitignore of dir, if there is one.
+func (g *gitIgnore) load(dir string) error {
+	file, err := os.Open(path.Join(dir, ".gitignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open .gitignore: %w", err)
+	}
+	defer file.Close()
+
+	var rules []pathPattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := newPathPattern(line)
+		if err != nil {
+			return fmt.Errorf("invalid .gitignore rule in %s: %w", dir, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	if len(rules) > 0 {
+		g.rules[dir] = rules
+	}
+	return nil
+}
+
+// ignored reports whether p is ignored, the last matching rule wins and rules
+// of deeper directories come last.
+func (g *gitIgnore) ignored(p string, isDir bool) bool {
+	var dirs []string
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel := p
+		if dirs[i] != "." {
+			rel = strings.TrimPrefix(p, strings.TrimSuffix(dirs[i], "/")+"/")
+		}
+		for _, rule := range g.rules[dirs[i]] {
+			if rule.match(rel, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+
+	return ignored
+}
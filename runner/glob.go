@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// pathPattern matches slash separated paths with gitignore style globs: '*'
+// and '?' don't match '/', '**' matches any number of directories. Patterns
+// without a slash match the base name at any depth.
+type pathPattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func newPathPattern(pattern string) (pathPattern, error) {
+	var p pathPattern
+
+	if strings.HasPrefix(pattern, "!") {
+		p.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return pathPattern{}, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	p.re = re
+
+	return p, nil
+}
+
+func (p pathPattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
+
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if !strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString("[^/]*")
+				break
+			}
+			i++
+			if strings.HasPrefix(pattern[i+1:], "/") {
+				b.WriteString("(?:.*/)?")
+				i++
+			} else {
+				b.WriteString(".*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				break
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// hasMeta reports whether path contains glob characters.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globBase returns the directory part of pattern before the first element
+// with glob characters.
+func globBase(pattern string) string {
+	elems := strings.Split(pattern, "/")
+	for i, elem := range elems {
+		if hasMeta(elem) {
+			if i == 0 {
+				return "."
+			}
+			base := strings.Join(elems[:i], "/")
+			if base == "" {
+				return "/"
+			}
+			return path.Clean(base)
+		}
+	}
+	return path.Clean(pattern)
+}
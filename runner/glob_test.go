@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{pattern: "*.md", path: "a.md", want: true},
+		{pattern: "*.md", path: "docs/deep/a.md", want: true},
+		{pattern: "*.md", path: "a.mdx", want: false},
+		{pattern: "docs/*.md", path: "docs/a.md", want: true},
+		{pattern: "docs/*.md", path: "docs/sub/a.md", want: false},
+		{pattern: "docs/**/*.md", path: "docs/a.md", want: true},
+		{pattern: "docs/**/*.md", path: "docs/sub/deep/a.md", want: true},
+		{pattern: "docs/**", path: "docs/sub/a.txt", want: true},
+		{pattern: "/build", path: "build", isDir: true, want: true},
+		{pattern: "/build", path: "src/build", isDir: true, want: false},
+		{pattern: "vendor/", path: "vendor", isDir: true, want: true},
+		{pattern: "vendor/", path: "vendor", want: false},
+		{pattern: "file?.txt", path: "file1.txt", want: true},
+		{pattern: "file[0-9].txt", path: "file5.txt", want: true},
+		{pattern: "file[!0-9].txt", path: "file5.txt", want: false},
+		{pattern: `\*.txt`, path: "*.txt", want: true},
+		{pattern: `\*.txt`, path: "a.txt", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			p, err := newPathPattern(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "docs/**/*.md", want: "docs"},
+		{pattern: "docs/sub/*.md", want: "docs/sub"},
+		{pattern: "*.md", want: "."},
+		{pattern: "/abs/dir/*.md", want: "/abs/dir"},
+		{pattern: "/*.md", want: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, globBase(tt.pattern))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mirpo/chopdoc/config"
+)
+
+// document is an input file, rel is its path relative to the directory or
+// glob base it was found in.
+type document struct {
+	path string
+	rel  string
+}
+
+type inputResolver struct {
+	include []pathPattern
+	exclude []pathPattern
+	ignore  *gitIgnore
+	seen    map[string]bool
+	docs    []document
+}
+
+// resolveInputs expands the input files, directories and glob patterns of cfg
+// into the list of documents to chunk. Directories are walked in lexical
+// order skipping hidden files, include and exclude patterns and .gitignore
+// files apply to files found in directories and by globs.
+func resolveInputs(cfg *config.Config) ([]document, error) {
+	r := &inputResolver{seen: make(map[string]bool)}
+
+	for _, pattern := range cfg.Include {
+		p, err := newPathPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern: %w", err)
+		}
+		r.include = append(r.include, p)
+	}
+	for _, pattern := range cfg.Exclude {
+		p, err := newPathPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+		r.exclude = append(r.exclude, p)
+	}
+	if cfg.GitIgnore {
+		r.ignore = newGitIgnore()
+	}
+
+	inputs := cfg.Inputs
+	if cfg.InputFile != "" {
+		inputs = append([]string{cfg.InputFile}, inputs...)
+	}
+
+	for _, input := range inputs {
+		if err := validatePath(input); err != nil {
+			return nil, fmt.Errorf("invalid input file path: %w", err)
+		}
+
+		if err := r.resolve(filepath.ToSlash(input)); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.docs, nil
+}
+
+func (r *inputResolver) resolve(input string) error {
+	if hasMeta(input) {
+		pattern := path.Clean(input)
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid input pattern '%s': %w", input, err)
+		}
+
+		base := globBase(pattern)
+		return r.walk(base, func(p string) bool { return re.MatchString(p) })
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+
+	if !info.IsDir() {
+		r.add(document{path: input, rel: path.Base(input)})
+		return nil
+	}
+
+	return r.walk(path.Clean(input), func(string) bool { return true })
+}
+
+func (r *inputResolver) walk(root string, match func(string) bool) error {
+	return filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read input directory: %w", err)
+		}
+
+		p = filepath.ToSlash(p)
+		rel := p
+		if root != "." {
+			rel, _ = filepath.Rel(root, p)
+			rel = filepath.ToSlash(rel)
+		}
+
+		if entry.IsDir() {
+			if p == root {
+				return r.loadIgnore(p)
+			}
+			if strings.HasPrefix(entry.Name(), ".") || r.skip(p, rel, true) {
+				return filepath.SkipDir
+			}
+			return r.loadIgnore(p)
+		}
+
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		if !match(p) || r.skip(p, rel, false) || !r.included(rel) {
+			return nil
+		}
+
+		r.add(document{path: p, rel: rel})
+		return nil
+	})
+}
+
+func (r *inputResolver) loadIgnore(dir string) error {
+	if r.ignore == nil {
+		return nil
+	}
+	return r.ignore.load(dir)
+}
+
+func (r *inputResolver) skip(p, rel string, isDir bool) bool {
+	if r.ignore != nil && r.ignore.ignored(p, isDir) {
+		return true
+	}
+
+	for _, pattern := range r.exclude {
+		if pattern.match(rel, isDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *inputResolver) included(rel string) bool {
+	if len(r.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range r.include {
+		if pattern.match(rel, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *inputResolver) add(doc document) {
+	if r.seen[doc.path] {
+		return
+	}
+	r.seen[doc.path] = true
+	r.docs = append(r.docs, doc)
+}
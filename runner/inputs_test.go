@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestResolveInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.md":              "a",
+		"docs/b.txt":             "b",
+		"docs/guide/c.md":        "c",
+		"docs/guide/d.log":       "d",
+		"docs/guide/keep.log":    "keep",
+		"docs/guide/.gitignore":  "*.log\n!keep.log\n",
+		"docs/vendor/e.md":       "e",
+		"docs/.gitignore":        "# comment\nvendor/\n",
+		"docs/.hidden/f.md":      "f",
+		"docs/node_modules/g.md": "g",
+		"notes.md":               "notes",
+	})
+
+	chdir(t, dir)
+
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		wantPaths []string
+		wantRels  []string
+		wantErr   string
+	}{
+		{
+			name:      "single file",
+			cfg:       config.Config{InputFile: "notes.md", GitIgnore: true},
+			wantPaths: []string{"notes.md"},
+			wantRels:  []string{"notes.md"},
+		},
+		{
+			name:      "directory honors gitignore",
+			cfg:       config.Config{Inputs: []string{"docs"}, GitIgnore: true},
+			wantPaths: []string{"docs/a.md", "docs/b.txt", "docs/guide/c.md", "docs/guide/keep.log", "docs/node_modules/g.md"},
+			wantRels:  []string{"a.md", "b.txt", "guide/c.md", "guide/keep.log", "node_modules/g.md"},
+		},
+		{
+			name: "directory without gitignore",
+			cfg:  config.Config{Inputs: []string{"docs"}},
+			wantPaths: []string{
+				"docs/a.md", "docs/b.txt", "docs/guide/c.md", "docs/guide/d.log", "docs/guide/keep.log",
+				"docs/node_modules/g.md", "docs/vendor/e.md",
+			},
+		},
+		{
+			name:      "include and exclude",
+			cfg:       config.Config{Inputs: []string{"docs"}, Include: []string{"*.md"}, Exclude: []string{"node_modules"}, GitIgnore: true},
+			wantPaths: []string{"docs/a.md", "docs/guide/c.md"},
+		},
+		{
+			name:      "recursive glob",
+			cfg:       config.Config{Inputs: []string{"docs/**/*.md"}, GitIgnore: true},
+			wantPaths: []string{"docs/a.md", "docs/guide/c.md", "docs/node_modules/g.md"},
+			wantRels:  []string{"a.md", "guide/c.md", "node_modules/g.md"},
+		},
+		{
+			name:      "glob in current directory",
+			cfg:       config.Config{Inputs: []string{"*.md"}, GitIgnore: true},
+			wantPaths: []string{"notes.md"},
+		},
+		{
+			name:      "multiple inputs without duplicates",
+			cfg:       config.Config{InputFile: "notes.md", Inputs: []string{"docs/*.md", "docs/a.md", "notes.md"}, GitIgnore: true},
+			wantPaths: []string{"notes.md", "docs/a.md"},
+		},
+		{
+			name:    "missing input",
+			cfg:     config.Config{Inputs: []string{"missing.md"}},
+			wantErr: "failed to open input file",
+		},
+		{
+			name:    "path traversal",
+			cfg:     config.Config{Inputs: []string{"../secret"}},
+			wantErr: "invalid input file path: path traversal detected",
+		},
+		{
+			name:    "invalid include pattern",
+			cfg:     config.Config{Inputs: []string{"docs"}, Include: []string{"[z-a]"}},
+			wantErr: "invalid include pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := resolveInputs(&tt.cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var paths, rels []string
+			for _, doc := range docs {
+				paths = append(paths, doc.path)
+				rels = append(rels, doc.rel)
+			}
+
+			assert.Equal(t, tt.wantPaths, paths)
+			if tt.wantRels != nil {
+				assert.Equal(t, tt.wantRels, rels)
+			}
+		})
+	}
+}
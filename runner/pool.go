@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mirpo/chopdoc/chopper"
+)
+
+type chopFunc func(ctx context.Context, doc document, sink chopper.ChunkSink) error
+
+type result struct {
+	index  int
+	output documentOutput
+	err    error
+}
+
+// documentOutput buffers the output of a document for the pool.
+type documentOutput struct {
+	started  bool
+	document string
+	chunks   []chopper.Chunk
+}
+
+func (o *documentOutput) WriteChunk(chunk chopper.Chunk) error {
+	o.chunks = append(o.chunks, chunk)
+	return nil
+}
+
+func (o *documentOutput) StartDocument(name string) error {
+	o.started, o.document = true, name
+	return nil
+}
+
+func (o *documentOutput) writeTo(sink chopper.ChunkSink) error {
+	if o.started {
+		if err := chopper.StartDocument(sink, o.document); err != nil {
+			return err
+		}
+	}
+	for _, chunk := range o.chunks {
+		if err := sink.WriteChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chopParallel runs chop for every document on a pool of workers. The chunks
+// of every document are buffered, so chunks of different documents never
+// interleave, and written to sink in input order unless unordered is set. A
+// document is handed out only while fewer than 2*workers documents are
+// buffered or being chopped, so a slow document doesn't make the pool buffer
+// the rest of the corpus. The first error cancels the remaining documents.
+func chopParallel(ctx context.Context, docs []document, workers int, unordered bool, chop chopFunc, sink chopper.ChunkSink) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	results := make(chan result)
+	// a slot for every document handed out and not written yet
+	window := make(chan struct{}, 2*workers)
+
+	var wg sync.WaitGroup
+	for range min(workers, len(docs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var output documentOutput
+				err := chop(ctx, docs[i], &output)
+
+				select {
+				case results <- result{index: i, output: output, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range docs {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return mergeResults(ctx, results, unordered, cancel, func() { <-window }, sink)
+}
+
+// mergeResults is the single writer of the pool, it keeps reading results
+// after an error so that workers can exit. written is called for every
+// document written.
+func mergeResults(ctx context.Context, results <-chan result, unordered bool, cancel context.CancelFunc, written func(), sink chopper.ChunkSink) error {
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	write := func(output documentOutput) {
+		if err := output.writeTo(sink); err != nil {
+			fail(err)
+		}
+		written()
+	}
+
+	pending := make(map[int]documentOutput)
+	next := 0
+	for res := range results {
+		if firstErr != nil {
+			continue
+		}
+		if res.err != nil {
+			fail(res.err)
+			continue
+		}
+
+		if unordered {
+			write(res.output)
+			continue
+		}
+
+		pending[res.index] = res.output
+		for output, ok := pending[next]; ok && firstErr == nil; output, ok = pending[next] {
+			write(output)
+			delete(pending, next)
+			next++
+		}
+	}
+
+	if firstErr == nil {
+		// results stop early only when the parent context is cancelled
+		return ctx.Err()
+	}
+	return firstErr
+}
@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCorpus(t *testing.T, dir string, n int) {
+	t.Helper()
+
+	files := make(map[string]string, n)
+	for i := range n {
+		files[fmt.Sprintf("docs/%03d.md", i)] = strings.Repeat(fmt.Sprintf("# Doc %d\nsection of document %d\n", i, i), i%5+1)
+	}
+	writeFiles(t, dir, files)
+}
+
+func runToFile(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+
+	require.NoError(t, NewRunner(cfg).Run())
+	data, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestParallel(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 50)
+	chdir(t, dir)
+
+	newConfig := func(output string, workers int, unordered bool) *config.Config {
+		return &config.Config{
+			Inputs:         []string{"docs"},
+			OutputFile:     output,
+			Method:         config.Markdown,
+			MarkdownLevels: []int{1},
+			AddMetadata:    true,
+			IDScheme:       config.IDOrdinal,
+			Workers:        workers,
+			Unordered:      unordered,
+		}
+	}
+
+	sequential := runToFile(t, newConfig("sequential.jsonl", 1, false))
+	ordered := runToFile(t, newConfig("ordered.jsonl", 8, false))
+	unordered := runToFile(t, newConfig("unordered.jsonl", 8, true))
+
+	assert.Equal(t, sequential, ordered)
+
+	lines := strings.Split(strings.TrimSpace(unordered), "\n")
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+
+	want := strings.Split(strings.TrimSpace(sequential), "\n")
+	slices.Sort(want)
+	slices.Sort(lines)
+	assert.Equal(t, want, lines)
+}
+
+func TestParallelOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 20)
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:         []string{"docs"},
+		OutputDir:      "out",
+		Method:         config.Markdown,
+		MarkdownLevels: []int{1},
+		Workers:        4,
+	}
+	require.NoError(t, NewRunner(cfg).Run())
+
+	for i := range 20 {
+		chunks := readChunks(t, filepath.Join("out", fmt.Sprintf("%03d.md.jsonl", i)))
+		assert.Len(t, chunks, i%5+1)
+	}
+}
+
+func TestParallelCancelsOnFirstError(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 50)
+	writeFiles(t, dir, map[string]string{"docs/010.md": "+++\ntitle = [\n+++\ntext\n"})
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:         []string{"docs"},
+		OutputFile:     "out.jsonl",
+		Method:         config.Markdown,
+		MarkdownLevels: []int{1},
+		Workers:        4,
+	}
+
+	err := NewRunner(cfg).Run()
+	assert.ErrorContains(t, err, "docs/010.md: failed to chop file: failed to parse TOML front matter")
+}
+
+func TestParallelContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 10)
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:     []string{"docs"},
+		OutputFile: "out.jsonl",
+		Method:     config.Char,
+		ChunkSize:  10,
+		Workers:    4,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, NewRunner(cfg).RunContext(ctx), context.Canceled)
+}
+
+func TestMergeResultsOrder(t *testing.T) {
+	results := make(chan result, 4)
+	results <- result{index: 2, output: documentOutput{chunks: []chopper.Chunk{{Text: "c"}}}}
+	results <- result{index: 0, output: documentOutput{chunks: []chopper.Chunk{{Text: "a"}}}}
+	results <- result{index: 3, output: documentOutput{chunks: []chopper.Chunk{{Text: "d"}}}}
+	results <- result{index: 1, output: documentOutput{chunks: []chopper.Chunk{{Text: "b"}}}}
+	close(results)
+
+	var out strings.Builder
+	err := mergeResults(context.Background(), results, false, func() {}, func() {}, chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		out.WriteString(chunk.Text)
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", out.String())
+}
+
+func TestParallelBoundsBufferedDocuments(t *testing.T) {
+	docs := make([]document, 100)
+	for i := range docs {
+		docs[i] = document{path: fmt.Sprintf("%03d.txt", i)}
+	}
+
+	var started, maxStarted atomic.Int32
+	chop := func(ctx context.Context, doc document, sink chopper.ChunkSink) error {
+		started.Add(1)
+		if doc.path == "000.txt" {
+			// the other workers keep chopping while the first document is slow
+			time.Sleep(100 * time.Millisecond)
+			maxStarted.Store(started.Load())
+		}
+		return sink.WriteChunk(chopper.Chunk{Source: doc.path})
+	}
+
+	var sources []string
+	err := chopParallel(context.Background(), docs, 4, false, chop, chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		sources = append(sources, chunk.Source)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, maxStarted.Load(), int32(8))
+	assert.Len(t, sources, 100)
+	assert.True(t, slices.IsSorted(sources))
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+// chopRecords chops the text field of every JSONL record as a document of
+// its own, the other fields of the record are added to the metadata of its
+// chunks.
+func (r *Runner) chopRecords(cfg *config.Config, name string, reader *bufio.Reader, sink chopper.ChunkSink) error {
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read jsonl input: %w", err)
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			if err := chopRecord(cfg, name, line, data, sink); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func chopRecord(cfg *config.Config, name string, line int, data []byte, sink chopper.ChunkSink) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep large integer ids as they are
+	decoder.UseNumber()
+
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return fmt.Errorf("invalid jsonl record: %w", err)
+	}
+
+	value, ok := record[cfg.TextField]
+	if !ok {
+		return fmt.Errorf("record has no %s field", cfg.TextField)
+	}
+	text, ok := value.(string)
+	if !ok && value != nil {
+		return fmt.Errorf("field %s of record is not a string", cfg.TextField)
+	}
+	delete(record, cfg.TextField)
+
+	recordCfg := *cfg
+	recordCfg.InputFile = recordSource(cfg.InputFile, line)
+
+	return chopText(&recordCfg, name, strings.NewReader(text), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		chunk.Metadata = recordMetadata(record, chunk.Metadata)
+		return sink.WriteChunk(chunk)
+	}))
+}
+
+// recordSource is the source of the chunks of the record at line of input,
+// chunk IDs of different records differ this way.
+func recordSource(input string, line int) string {
+	if input == "" {
+		return strconv.Itoa(line)
+	}
+	return input + ":" + strconv.Itoa(line)
+}
+
+// recordMetadata merges the fields of a record and the metadata of one of its
+// chunks, the chunk metadata wins.
+func recordMetadata(record, metadata map[string]any) map[string]any {
+	if len(record) == 0 {
+		return metadata
+	}
+
+	merged := make(map[string]any, len(record)+len(metadata))
+	maps.Copy(merged, record)
+	maps.Copy(merged, metadata)
+
+	return merged
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLInput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"records.jsonl.gz": gzipText(t, `{"id":9007199254740993,"body":"First record.","url":"https://example.com/1"}
+
+{"id":2,"body":"Second record. It is longer.","tags":["a","b"]}
+{"id":3,"body":null}
+`),
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"records.jsonl.gz"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Method = config.Sentence
+	cfg.ChunkSize = 1
+	cfg.IDScheme = config.IDOrdinal
+	cfg.InputFormat = config.InputJSONL
+	cfg.TextField = "body"
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	// ids keep their precision in output
+	output, err := os.ReadFile("out.jsonl")
+	require.NoError(t, err)
+	assert.Contains(t, string(output), `"id":9007199254740993`)
+
+	chunks := parseChunks(t, output)
+	for i := range chunks {
+		chunks[i].SHA256 = ""
+	}
+
+	assert.Equal(t, []chopper.Chunk{
+		{ID: "records.jsonl.gz:1#0", Source: "records.jsonl.gz:1", Text: "First record.", Metadata: map[string]any{
+			"id": 9.007199254740992e+15, "url": "https://example.com/1",
+		}},
+		{ID: "records.jsonl.gz:3#0", Source: "records.jsonl.gz:3", Text: "Second record.", Metadata: map[string]any{
+			"id": float64(2), "tags": []any{"a", "b"},
+		}},
+		{ID: "records.jsonl.gz:3#1", Source: "records.jsonl.gz:3", Text: "It is longer.", Metadata: map[string]any{
+			"id": float64(2), "tags": []any{"a", "b"},
+		}},
+	}, chunks)
+}
+
+func TestJSONLInputErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "missing text field",
+			input:   "{\"body\":\"text\"}\n{\"title\":\"no body\"}\n",
+			wantErr: "records.jsonl: line 2: record has no body field",
+		},
+		{
+			name:    "text field not a string",
+			input:   `{"body":42}`,
+			wantErr: "records.jsonl: line 1: field body of record is not a string",
+		},
+		{
+			name:    "invalid record",
+			input:   `["body"]`,
+			wantErr: "records.jsonl: line 1: invalid jsonl record",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"records.jsonl": tt.input})
+			chdir(t, dir)
+
+			cfg := config.NewConfig()
+			cfg.Inputs = []string{"records.jsonl"}
+			cfg.OutputFile = "out.jsonl"
+			cfg.InputFormat = config.InputJSONL
+			cfg.TextField = "body"
+			require.NoError(t, cfg.Validate())
+
+			err := NewRunner(cfg).Run()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+)
+
+// rowDocument is a row of a table, or the rows sharing a group-by value,
+// starting at line of the input.
+type rowDocument struct {
+	line int
+	rows [][]string
+}
+
+// chopRows chops the rows of a CSV or TSV table as documents of their own, a
+// row each or, with GroupBy, the rows sharing a value of the GroupBy column.
+// TextColumns renders every row into the text of its document and the other
+// columns are added to the chunk metadata. The table method chops the rows as
+// a table instead, auto picks it for tables without TextColumns only.
+func (r *Runner) chopRows(cfg *config.Config, name string, reader io.Reader, sink chopper.ChunkSink) error {
+	if cfg.Method == config.Auto {
+		resolved := *cfg
+		resolved.Method, resolved.Language = cfg.ResolveMethod(name)
+		// rows rendered by text columns are text, not a table
+		if resolved.Method == config.Table && cfg.TextColumns != "" {
+			resolved.Method = config.Recursive
+		}
+		cfg = &resolved
+	}
+
+	if cfg.Method == config.Table && cfg.GroupBy == "" {
+		return chopText(cfg, name, reader, sink)
+	}
+
+	table := csv.NewReader(reader)
+	table.Comma = cfg.TableComma(name)
+
+	header, err := chopper.ReadTableHeader(table)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read table: %w", err)
+	}
+
+	var template *rowTemplate
+	if cfg.Method != config.Table {
+		if template, err = parseRowTemplate(cfg.TextColumns, header); err != nil {
+			return err
+		}
+	}
+
+	group := -1
+	if cfg.GroupBy != "" {
+		if group = slices.Index(header, cfg.GroupBy); group < 0 {
+			return fmt.Errorf("unknown group-by column %s", cfg.GroupBy)
+		}
+	}
+
+	// groups are chopped once the whole table is read, in order of their
+	// first row
+	var groups []*rowDocument
+	groupByKey := make(map[string]*rowDocument)
+	for {
+		row, err := table.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read table: %w", err)
+		}
+		line, _ := table.FieldPos(0)
+
+		if group < 0 {
+			if err := chopRowDocument(cfg, header, template, &rowDocument{line: line, rows: [][]string{row}}, sink); err != nil {
+				return err
+			}
+			continue
+		}
+
+		doc, ok := groupByKey[row[group]]
+		if !ok {
+			doc = &rowDocument{line: line}
+			groupByKey[row[group]] = doc
+			groups = append(groups, doc)
+		}
+		doc.rows = append(doc.rows, row)
+	}
+
+	for _, doc := range groups {
+		if err := chopRowDocument(cfg, header, template, doc, sink); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func chopRowDocument(cfg *config.Config, header []string, template *rowTemplate, doc *rowDocument, sink chopper.ChunkSink) error {
+	docCfg := *cfg
+	docCfg.InputFile = recordSource(cfg.InputFile, doc.line)
+
+	var text string
+	var metadata map[string]any
+	if template == nil {
+		var table strings.Builder
+		writer := csv.NewWriter(&table)
+		writer.Comma = cfg.TableComma(cfg.InputFile)
+		if err := writer.WriteAll(append([][]string{header}, doc.rows...)); err != nil {
+			return fmt.Errorf("failed to write table: %w", err)
+		}
+		text = table.String()
+		metadata = map[string]any{cfg.GroupBy: doc.rows[0][slices.Index(header, cfg.GroupBy)]}
+	} else {
+		texts := make([]string, 0, len(doc.rows))
+		for _, row := range doc.rows {
+			texts = append(texts, template.render(row))
+		}
+		text = strings.Join(texts, "\n\n")
+		metadata = sharedColumns(header, doc.rows, template.columns)
+	}
+
+	err := chopText(&docCfg, docCfg.InputFile, strings.NewReader(text), chopper.SinkFunc(func(chunk chopper.Chunk) error {
+		chunk.Metadata = recordMetadata(metadata, chunk.Metadata)
+		return sink.WriteChunk(chunk)
+	}))
+	if err != nil {
+		return fmt.Errorf("line %d: %w", doc.line, err)
+	}
+	return nil
+}
+
+// sharedColumns returns the non-empty values of the columns, other than
+// skipped ones, which are the same in all rows.
+func sharedColumns(header []string, rows [][]string, skipped []int) map[string]any {
+	shared := make(map[string]any)
+	for i, column := range header {
+		if slices.Contains(skipped, i) || rows[0][i] == "" {
+			continue
+		}
+		if slices.ContainsFunc(rows, func(row []string) bool { return row[i] != rows[0][i] }) {
+			continue
+		}
+		shared[column] = rows[0][i]
+	}
+	return shared
+}
+
+var columnRgx = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// rowTemplate renders a table row into text, {column} placeholders are
+// replaced with the values of the columns.
+type rowTemplate struct {
+	parts []string
+	// column of every placeholder, parts and placeholders alternate
+	columns []int
+}
+
+// parseRowTemplate parses a template like 'Q: {question}\nA: {answer}' where
+// Go escape sequences like \n are interpreted.
+func parseRowTemplate(textColumns string, header []string) (*rowTemplate, error) {
+	if textColumns == "" {
+		return nil, fmt.Errorf("text columns are required for csv input, unless the method is table")
+	}
+	if unquoted, err := strconv.Unquote(`"` + textColumns + `"`); err == nil {
+		textColumns = unquoted
+	}
+
+	template := &rowTemplate{}
+	last := 0
+	for _, match := range columnRgx.FindAllStringSubmatchIndex(textColumns, -1) {
+		column := strings.TrimSpace(textColumns[match[2]:match[3]])
+		index := slices.Index(header, column)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column %s in text columns", column)
+		}
+
+		template.parts = append(template.parts, textColumns[last:match[0]])
+		template.columns = append(template.columns, index)
+		last = match[1]
+	}
+	if len(template.columns) == 0 {
+		return nil, fmt.Errorf("text columns have no {column} placeholders")
+	}
+	template.parts = append(template.parts, textColumns[last:])
+
+	return template, nil
+}
+
+func (t *rowTemplate) render(row []string) string {
+	var text strings.Builder
+	for i, column := range t.columns {
+		text.WriteString(t.parts[i])
+		text.WriteString(row[column])
+	}
+	text.WriteString(t.parts[len(t.parts)-1])
+
+	return text.String()
+}
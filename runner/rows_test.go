@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/mirpo/chopdoc/chopper"
+	"github.com/mirpo/chopdoc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const faqCSV = "\ufefftopic,question,answer,owner\n" +
+	"install,How to install?,Run go install.,ann\n" +
+	"usage,How to pipe?,\"Use cat, then chopdoc.\",bob\n" +
+	"install,Which Go version?,Go 1.23.,ann\n"
+
+func TestCSVInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		input    string
+		format   config.InputFormat
+		method   config.ChunkMethod
+		size     int
+		groupBy  string
+		expected []chopper.Chunk
+	}{
+		{
+			name:   "row per document",
+			file:   "faq.csv",
+			input:  faqCSV,
+			format: config.InputCSV,
+			method: config.Recursive,
+			size:   1000,
+			expected: []chopper.Chunk{
+				{Source: "faq.csv:2", Text: "Q: How to install?\nA: Run go install.", Metadata: map[string]any{"topic": "install", "owner": "ann"}},
+				{Source: "faq.csv:3", Text: "Q: How to pipe?\nA: Use cat, then chopdoc.", Metadata: map[string]any{"topic": "usage", "owner": "bob"}},
+				{Source: "faq.csv:4", Text: "Q: Which Go version?\nA: Go 1.23.", Metadata: map[string]any{"topic": "install", "owner": "ann"}},
+			},
+		},
+		{
+			name:    "grouped rows",
+			file:    "faq.tsv",
+			input:   "topic\tquestion\tanswer\towner\ninstall\tHow to install?\tRun go install.\tann\nusage\tHow to pipe?\tUse cat.\tbob\ninstall\tWhich Go version?\tGo 1.23.\tcid\n",
+			format:  config.InputTSV,
+			method:  config.Recursive,
+			size:    1000,
+			groupBy: "topic",
+			expected: []chopper.Chunk{
+				{Source: "faq.tsv:2", Text: "Q: How to install?\nA: Run go install.\n\nQ: Which Go version?\nA: Go 1.23.", Metadata: map[string]any{"topic": "install"}},
+				{Source: "faq.tsv:3", Text: "Q: How to pipe?\nA: Use cat.", Metadata: map[string]any{"topic": "usage", "owner": "bob"}},
+			},
+		},
+		{
+			name:   "auto with text columns",
+			file:   "faq.csv",
+			input:  faqCSV,
+			format: config.InputCSV,
+			method: config.Auto,
+			size:   1000,
+			expected: []chopper.Chunk{
+				{Source: "faq.csv:2", Text: "Q: How to install?\nA: Run go install.", Metadata: map[string]any{"topic": "install", "owner": "ann"}},
+				{Source: "faq.csv:3", Text: "Q: How to pipe?\nA: Use cat, then chopdoc.", Metadata: map[string]any{"topic": "usage", "owner": "bob"}},
+				{Source: "faq.csv:4", Text: "Q: Which Go version?\nA: Go 1.23.", Metadata: map[string]any{"topic": "install", "owner": "ann"}},
+			},
+		},
+		{
+			name:   "table",
+			file:   "faq.csv",
+			input:  faqCSV,
+			format: config.InputCSV,
+			method: config.Table,
+			size:   2,
+			expected: []chopper.Chunk{
+				{Source: "faq.csv", Text: "topic,question,answer,owner\ninstall,How to install?,Run go install.,ann\nusage,How to pipe?,\"Use cat, then chopdoc.\",bob\n"},
+				{Source: "faq.csv", Text: "topic,question,answer,owner\ninstall,Which Go version?,Go 1.23.,ann\n"},
+			},
+		},
+		{
+			name:    "grouped table",
+			file:    "faq.csv",
+			input:   faqCSV,
+			format:  config.InputCSV,
+			method:  config.Table,
+			size:    10,
+			groupBy: "topic",
+			expected: []chopper.Chunk{
+				{Source: "faq.csv:2", Text: "topic,question,answer,owner\ninstall,How to install?,Run go install.,ann\ninstall,Which Go version?,Go 1.23.,ann\n", Metadata: map[string]any{"topic": "install"}},
+				{Source: "faq.csv:3", Text: "topic,question,answer,owner\nusage,How to pipe?,\"Use cat, then chopdoc.\",bob\n", Metadata: map[string]any{"topic": "usage"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{tt.file: tt.input})
+			chdir(t, dir)
+
+			cfg := config.NewConfig()
+			cfg.Inputs = []string{tt.file}
+			cfg.OutputFile = "out.jsonl"
+			cfg.Method = tt.method
+			cfg.ChunkSize = tt.size
+			cfg.InputFormat = tt.format
+			cfg.TextColumns = `Q: {question}\nA: {answer}`
+			cfg.GroupBy = tt.groupBy
+			require.NoError(t, cfg.Validate())
+			require.NoError(t, NewRunner(cfg).Run())
+
+			assert.Equal(t, tt.expected, readChunks(t, "out.jsonl"))
+		})
+	}
+}
+
+func TestCSVInputErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		textColumns string
+		groupBy     string
+		wantErr     string
+	}{
+		{
+			name:        "unknown column",
+			input:       faqCSV,
+			textColumns: "{question} {reply}",
+			wantErr:     "faq.csv: unknown column reply in text columns",
+		},
+		{
+			name:        "no placeholders",
+			input:       faqCSV,
+			textColumns: "question",
+			wantErr:     "faq.csv: text columns have no {column} placeholders",
+		},
+		{
+			name:        "unknown group-by column",
+			input:       faqCSV,
+			textColumns: "{question}",
+			groupBy:     "team",
+			wantErr:     "faq.csv: unknown group-by column team",
+		},
+		{
+			name:        "ragged row",
+			input:       "question,answer\nHow?\n",
+			textColumns: "{question}",
+			wantErr:     "faq.csv: failed to read table",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"faq.csv": tt.input})
+			chdir(t, dir)
+
+			cfg := config.NewConfig()
+			cfg.Inputs = []string{"faq.csv"}
+			cfg.OutputFile = "out.jsonl"
+			cfg.Method = config.Recursive
+			cfg.InputFormat = config.InputCSV
+			cfg.TextColumns = tt.textColumns
+			cfg.GroupBy = tt.groupBy
+			require.NoError(t, cfg.Validate())
+
+			err := NewRunner(cfg).Run()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -2,86 +2,312 @@ package runner
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/mirpo/chopdoc/chopper"
 	"github.com/mirpo/chopdoc/config"
+	"github.com/mirpo/chopdoc/decoder"
+	"github.com/mirpo/chopdoc/embedding"
+	"github.com/mirpo/chopdoc/output"
 )
 
 type Runner struct {
-	cfg *config.Config
+	cfg      *config.Config
+	embedder *embedding.Embedder
 }
 
 func NewRunner(cfg *config.Config) *Runner {
-	return &Runner{
+	r := &Runner{
 		cfg: cfg,
 	}
+	if cfg.Embed {
+		r.embedder = embedding.NewEmbedder(cfg)
+	}
+	return r
 }
 
 func (r *Runner) Run() error {
-	var input *os.File
-	var err error
+	return r.RunContext(context.Background())
+}
 
+// RunContext chops all inputs, with more than one worker inputs are chopped in
+// parallel. Cancelling ctx stops the run.
+func (r *Runner) RunContext(ctx context.Context) error {
 	if r.cfg.Piped {
-		input = os.Stdin
-	} else {
-		if err := validatePath(r.cfg.InputFile); err != nil {
-			return fmt.Errorf("invalid input file path: %w", err)
-		}
-		absPath, err := filepath.Abs(r.cfg.InputFile)
-		if err != nil {
+		return r.writeOutput(ctx, r.cfg.OutputFile, func(sink chopper.ChunkSink) error {
+			if err := chopper.StartDocument(sink, r.cfg.InputFile); err != nil {
+				return err
+			}
+			return r.chop(ctx, r.cfg, os.Stdin, sink)
+		})
+	}
+
+	docs, err := resolveInputs(r.cfg)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no input files found")
+	}
+
+	if r.cfg.OutputDir != "" {
+		return r.runPerInput(ctx, docs)
+	}
+
+	return r.writeOutput(ctx, r.cfg.OutputFile, func(sink chopper.ChunkSink) error {
+		return r.forEach(ctx, docs, r.chopFile, sink)
+	})
+}
+
+// forEach chops docs one after another, or on a worker pool when more than
+// one worker is configured.
+func (r *Runner) forEach(ctx context.Context, docs []document, chop chopFunc, sink chopper.ChunkSink) error {
+	if r.cfg.Workers > 1 && len(docs) > 1 {
+		return chopParallel(ctx, docs, r.cfg.Workers, r.cfg.Unordered, chop, sink)
+	}
+
+	for _, doc := range docs {
+		if err := chop(ctx, doc, sink); err != nil {
 			return err
 		}
-		input, err = os.Open(absPath)
-		if err != nil {
-			return fmt.Errorf("failed to open input file: %w", err)
+	}
+	return nil
+}
+
+// runPerInput writes the chunks of every document into its own file in the
+// output directory, mirroring the input tree.
+func (r *Runner) runPerInput(ctx context.Context, docs []document) error {
+	outputs := make(map[string]string, len(docs))
+	for _, doc := range docs {
+		output := r.outputPath(doc)
+		if other, ok := outputs[output]; ok {
+			return fmt.Errorf("inputs %s and %s have the same output file %s", other, doc.path, output)
+		}
+		outputs[output] = doc.path
+	}
+
+	return r.forEach(ctx, docs, func(ctx context.Context, doc document, _ chopper.ChunkSink) error {
+		output := r.outputPath(doc)
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
 		}
-		defer input.Close()
+
+		return r.writeOutput(ctx, output, func(sink chopper.ChunkSink) error {
+			return r.chopFile(ctx, doc, sink)
+		})
+	}, nil)
+}
+
+func (r *Runner) outputPath(doc document) string {
+	format := r.cfg.OutputFormat
+	if format == "" {
+		format = config.FormatJSONL
 	}
+	return filepath.Join(r.cfg.OutputDir, filepath.FromSlash(doc.rel)+"."+string(format))
+}
 
-	var output *os.File
-	if r.cfg.OutputFile != "" {
-		if err := validatePath(r.cfg.OutputFile); err != nil {
+// writeOutput opens the output file, or stdout when path is empty, write
+// writes chunks into it in the output format. The output is finished and
+// flushed after write is done.
+func (r *Runner) writeOutput(ctx context.Context, path string, write func(sink chopper.ChunkSink) error) error {
+	if r.embedder != nil {
+		write = r.embed(ctx, write)
+	}
+
+	if r.cfg.OutputFormat == config.FormatSQLite {
+		return r.writeDatabase(path, write)
+	}
+
+	var file *os.File
+	if path != "" {
+		if err := validatePath(path); err != nil {
 			return fmt.Errorf("invalid output file path: %w", err)
 		}
-		absPath, err := filepath.Abs(r.cfg.OutputFile)
+		absPath, err := filepath.Abs(path)
 		if err != nil {
 			return err
 		}
-		output, err = os.Create(absPath)
+		file, err = os.Create(absPath)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
-		defer output.Close()
+		defer file.Close()
 	} else {
-		output = os.Stdout
+		file = os.Stdout
+	}
+
+	writer := bufio.NewWriter(file)
+	sink, err := output.NewWriter(r.cfg, writer)
+	if err != nil {
+		return err
+	}
+
+	if err := write(sink); err != nil {
+		return err
+	}
+
+	if err := sink.Close(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush buffers: %w", err)
+	}
+
+	return nil
+}
+
+// embed wraps write to embed the chunks before they are written into the
+// output.
+func (r *Runner) embed(ctx context.Context, write func(sink chopper.ChunkSink) error) func(sink chopper.ChunkSink) error {
+	return func(sink chopper.ChunkSink) error {
+		// requests in flight are cancelled when write fails
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		embedded, flush := r.embedder.Sink(ctx, sink)
+		if err := write(embedded); err != nil {
+			return err
+		}
+		return flush()
 	}
+}
 
-	reader := bufio.NewReader(input)
-	writer := bufio.NewWriter(output)
-	rw := bufio.NewReadWriter(reader, writer)
+// writeDatabase writes chunks into the SQLite database at path, the chunks of
+// a failed source are rolled back.
+func (r *Runner) writeDatabase(path string, write func(sink chopper.ChunkSink) error) error {
+	if err := validatePath(path); err != nil {
+		return fmt.Errorf("invalid output file path: %w", err)
+	}
 
-	chopper, err := chopper.NewChopper(r.cfg.Method, r.cfg, rw)
+	db, err := output.OpenSQLite(path)
 	if err != nil {
-		return fmt.Errorf("failed to create chopper: %w", err)
+		return err
 	}
 
-	err = chopper.Chop()
+	if err := write(db); err != nil {
+		db.Rollback()
+		return err
+	}
+
+	return db.Close()
+}
+
+// chopFile chops a single document, its path is the source of the chunks.
+func (r *Runner) chopFile(ctx context.Context, doc document, sink chopper.ChunkSink) error {
+	absPath, err := filepath.Abs(doc.path)
 	if err != nil {
-		return fmt.Errorf("failed to chop file: %w", err)
+		return err
+	}
+	input, err := os.Open(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer input.Close()
+
+	cfg := *r.cfg
+	cfg.InputFile = doc.path
+
+	if err := chopper.StartDocument(sink, doc.path); err != nil {
+		return err
+	}
+	if err := r.chop(ctx, &cfg, input, sink); err != nil {
+		return fmt.Errorf("%s: %w", doc.path, err)
+	}
+
+	return nil
+}
+
+func (r *Runner) chop(ctx context.Context, cfg *config.Config, input io.Reader, sink chopper.ChunkSink) error {
+	return r.chopStream(ctx, cfg, cfg.InputFile, input, sink)
+}
+
+// chopStream chops the document name read from input. Compressed streams are
+// decompressed, documents like PDF are decoded and archive members are
+// chopped as documents of their own, all detected by the start of the input
+// or by the extension of name.
+func (r *Runner) chopStream(ctx context.Context, cfg *config.Config, name string, input io.Reader, sink chopper.ChunkSink) error {
+	reader := bufio.NewReader(chopper.ContextReader(ctx, input))
+
+	// a short input is detected by what could be read
+	head, _ := reader.Peek(512)
+
+	if compression := detectCompression(head); compression != nil {
+		decompressed, err := compression.open(reader)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s input: %w", compression.name, err)
+		}
+		defer decompressed.Close()
+
+		return r.chopStream(ctx, cfg, compression.decompressedName(name), decompressed, sink)
+	}
+
+	format := decoder.Detect(name, head)
+	switch {
+	case format == nil && isZip(head):
+		return r.chopZip(ctx, cfg, input, reader, sink)
+	case format == nil && isTar(name, head):
+		return r.chopTar(ctx, cfg, reader, sink)
 	}
 
-	err = rw.Flush()
+	switch cfg.InputFormat {
+	case config.InputJSONL:
+		return r.chopRecords(cfg, name, reader, sink)
+	case config.InputCSV, config.InputTSV:
+		return r.chopRows(cfg, name, reader, sink)
+	}
+
+	text, err := decode(format, reader)
 	if err != nil {
-		return fmt.Errorf("failed to flush buffers: %w", err)
+		return err
+	}
+
+	return chopText(cfg, name, text, sink)
+}
+
+// chopText chops the text of the document name into sink.
+func chopText(cfg *config.Config, name string, text io.Reader, sink chopper.ChunkSink) error {
+	if cfg.Method == config.Auto {
+		// name has no compression extension, unlike the source
+		resolved := *cfg
+		resolved.Method, resolved.Language = cfg.ResolveMethod(name)
+		cfg = &resolved
+	}
+
+	chopper, err := chopper.NewChopper(cfg.Method, cfg, text, sink)
+	if err != nil {
+		return fmt.Errorf("failed to create chopper: %w", err)
+	}
+
+	if err := chopper.Chop(); err != nil {
+		return fmt.Errorf("failed to chop file: %w", err)
 	}
 
 	return nil
 }
 
+// decode converts documents of format, like PDF, into text. Plain text is
+// read as is.
+func decode(format *decoder.Format, r io.Reader) (io.Reader, error) {
+	if format == nil {
+		return r, nil
+	}
+
+	doc, err := format.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s input: %w", format.Name, err)
+	}
+
+	if doc.Pages != nil {
+		return chopper.NewPagedReader(doc.Pages), nil
+	}
+	return strings.NewReader(doc.Text), nil
+}
+
 func validatePath(path string) error {
 	if strings.Contains(path, "..") {
 		return fmt.Errorf("path traversal detected: %s", path)
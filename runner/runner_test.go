@@ -1,11 +1,15 @@
 package runner
 
 import (
-	"bufio"
+	"archive/zip"
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -308,6 +312,7 @@ func TestSentence(t *testing.T) {
 		input      string
 		chunkSize  int
 		overlap    int
+		lengthUnit config.LengthUnit
 		wantChunks []string
 		wantErr    bool
 	}{
@@ -343,6 +348,41 @@ func TestSentence(t *testing.T) {
 				"chunking three!.",
 			},
 		},
+		{
+			name:       "size in runes",
+			input:      "basic chunking one.   chunking two? chunking three!.",
+			chunkSize:  35,
+			overlap:    0,
+			lengthUnit: config.Runes,
+			wantChunks: []string{
+				"basic chunking one. chunking two?",
+				"chunking three!.",
+			},
+		},
+		{
+			name:       "size and overlap in words",
+			input:      "one two. three four. five six. seven.",
+			chunkSize:  4,
+			overlap:    2,
+			lengthUnit: config.Words,
+			wantChunks: []string{
+				"one two. three four.",
+				"three four. five six.",
+				"five six. seven.",
+			},
+		},
+		{
+			name:       "sentence longer than size",
+			input:      "basic chunking one.   chunking two? chunking three!.",
+			chunkSize:  3,
+			overlap:    0,
+			lengthUnit: config.Words,
+			wantChunks: []string{
+				"basic chunking one.",
+				"chunking two?",
+				"chunking three!.",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -359,6 +399,7 @@ func TestSentence(t *testing.T) {
 				ChunkSize:  tt.chunkSize,
 				Overlap:    tt.overlap,
 				Method:     config.Sentence,
+				LengthUnit: tt.lengthUnit,
 			}
 
 			r := NewRunner(cfg)
@@ -399,6 +440,7 @@ func TestRecursive(t *testing.T) {
 		input      string
 		chunkSize  int
 		overlap    int
+		lengthUnit config.LengthUnit
 		wantChunks []string
 		wantErr    bool
 	}{
@@ -444,8 +486,54 @@ func TestRecursive(t *testing.T) {
 			overlap:   0,
 			wantChunks: []string{
 				"basic chunking one.\n\t\t\t\n\t\t\tchunking two?",
-				"chunking three!.",
-				"chunking four!.",
+				"chunking three!.\n\t\t\t\n\t\t\t\n\t\t\tchunking four!.",
+			},
+		},
+		{
+			name:      "with overlap",
+			input:     "one two three four five six seven",
+			chunkSize: 14,
+			overlap:   6,
+			wantChunks: []string{
+				"one two three",
+				"three four",
+				"four five six",
+				"six seven",
+			},
+		},
+		{
+			name: "overlap never exceeds chunk size",
+			input: `first paragraph is here.
+
+second paragraph follows.`,
+			chunkSize: 30,
+			overlap:   20,
+			wantChunks: []string{
+				"first paragraph is here.",
+				"second paragraph follows.",
+			},
+		},
+		{
+			name:       "size in words",
+			input:      "one two three four five six seven",
+			chunkSize:  3,
+			overlap:    0,
+			lengthUnit: config.Words,
+			wantChunks: []string{
+				"one two three",
+				"four five six",
+				"seven",
+			},
+		},
+		{
+			name:       "size in tokens",
+			input:      "one two three four five six seven",
+			chunkSize:  4,
+			overlap:    0,
+			lengthUnit: config.Tokens,
+			wantChunks: []string{
+				"one two three four",
+				"five six seven",
 			},
 		},
 	}
@@ -465,6 +553,8 @@ func TestRecursive(t *testing.T) {
 				Overlap:      tt.overlap,
 				Method:       config.Recursive,
 				CleaningMode: config.CleanTrim,
+				LengthUnit:   tt.lengthUnit,
+				Tokenizer:    "cl100k_base",
 			}
 
 			r := NewRunner(cfg)
@@ -497,6 +587,91 @@ func TestRecursive(t *testing.T) {
 	}
 }
 
+func TestToken(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		input      string
+		chunkSize  int
+		overlap    int
+		wantChunks []string
+	}{
+		{
+			name:      "basic chunking",
+			input:     "one two three four five six seven",
+			chunkSize: 3,
+			overlap:   0,
+			wantChunks: []string{
+				"one two three",
+				" four five six",
+				" seven",
+			},
+		},
+		{
+			name:      "with overlap",
+			input:     "one two three four five six seven",
+			chunkSize: 3,
+			overlap:   1,
+			wantChunks: []string{
+				"one two three",
+				" three four five",
+				" five six seven",
+			},
+		},
+		{
+			name:      "runes are never split between chunks",
+			input:     "héllo wörld 世界 🌍🌍",
+			chunkSize: 8,
+			overlap:   0,
+			wantChunks: []string{
+				"héllo wörld ",
+				"世界 🌍",
+				"🌍",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inPath := filepath.Join(tmpDir, "input.txt")
+			err := os.WriteFile(inPath, []byte(tt.input), 0o644)
+			require.NoError(t, err)
+
+			outPath := filepath.Join(tmpDir, "output.jsonl")
+
+			cfg := &config.Config{
+				InputFile:  inPath,
+				OutputFile: outPath,
+				ChunkSize:  tt.chunkSize,
+				Overlap:    tt.overlap,
+				Method:     config.Token,
+				Tokenizer:  "cl100k_base",
+			}
+
+			r := NewRunner(cfg)
+			require.NoError(t, r.Run())
+
+			f, err := os.Open(outPath)
+			require.NoError(t, err)
+			defer f.Close()
+
+			var chunks []chopper.Chunk
+			dec := json.NewDecoder(f)
+			for dec.More() {
+				var chunk chopper.Chunk
+				require.NoError(t, dec.Decode(&chunk))
+				chunks = append(chunks, chunk)
+			}
+
+			assert.Equal(t, len(tt.wantChunks), len(chunks))
+			for i, want := range tt.wantChunks {
+				assert.Equal(t, want, chunks[i].Text)
+			}
+		})
+	}
+}
+
 func TestMarkdownChopper(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -513,8 +688,13 @@ func TestMarkdownChopper(t *testing.T) {
 			strip:   false,
 			addMeta: true,
 			wantChunks: []chopper.Chunk{
-				{Text: "# Header 1\nContent under header 1\n", Metadata: map[string]string{"Header 1": "Header 1"}},
-				{Text: "## Header 2\nContent under header 2\n", Metadata: map[string]string{"Header 1": "Header 1", "Header 2": "Header 2"}},
+				{Text: "# Header 1\nContent under header 1\n\n", Metadata: map[string]any{
+					"Header 1": "Header 1", "breadcrumb": []any{"Header 1"}, "h_path": "Header 1",
+				}},
+				{Text: "## Header 2\nContent under header 2\n", Metadata: map[string]any{
+					"Header 1": "Header 1", "Header 2": "Header 2",
+					"breadcrumb": []any{"Header 1", "Header 2"}, "h_path": "Header 1 > Header 2",
+				}},
 			},
 		},
 		{
@@ -524,8 +704,13 @@ func TestMarkdownChopper(t *testing.T) {
 			strip:   true,
 			addMeta: true,
 			wantChunks: []chopper.Chunk{
-				{Text: "Content under header 1\n", Metadata: map[string]string{"Header 1": "Header 1"}},
-				{Text: "Content under header 2\n", Metadata: map[string]string{"Header 1": "Header 1", "Header 2": "Header 2"}},
+				{Text: "Content under header 1\n\n", Metadata: map[string]any{
+					"Header 1": "Header 1", "breadcrumb": []any{"Header 1"}, "h_path": "Header 1",
+				}},
+				{Text: "Content under header 2\n", Metadata: map[string]any{
+					"Header 1": "Header 1", "Header 2": "Header 2",
+					"breadcrumb": []any{"Header 1", "Header 2"}, "h_path": "Header 1 > Header 2",
+				}},
 			},
 		},
 	}
@@ -533,7 +718,6 @@ func TestMarkdownChopper(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var output bytes.Buffer
-			rw := bufio.NewReadWriter(bufio.NewReader(strings.NewReader(tt.input)), bufio.NewWriter(&output))
 
 			cfg := &config.Config{
 				MarkdownLevels: tt.levels,
@@ -541,11 +725,11 @@ func TestMarkdownChopper(t *testing.T) {
 				AddMetadata:    tt.addMeta,
 			}
 
-			markdownChopper := chopper.NewMarkdownChopper(cfg, rw)
-			err := markdownChopper.Chop()
+			markdownChopper, err := chopper.NewMarkdownChopper(cfg, strings.NewReader(tt.input), chopper.NewJSONLSink(&output))
+			require.NoError(t, err)
 
+			err = markdownChopper.Chop()
 			require.NoError(t, err)
-			require.NoError(t, rw.Flush())
 
 			var gotChunks []chopper.Chunk
 			decoder := json.NewDecoder(&output)
@@ -631,3 +815,409 @@ func TestPiped(t *testing.T) {
 		})
 	}
 }
+
+func readChunks(t *testing.T, path string) []chopper.Chunk {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return parseChunks(t, data)
+}
+
+func parseChunks(t *testing.T, data []byte) []chopper.Chunk {
+	t.Helper()
+
+	var chunks []chopper.Chunk
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var chunk chopper.Chunk
+		require.NoError(t, decoder.Decode(&chunk))
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+func TestMultipleInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.md":       "# A\nfirst\n",
+		"docs/guide/b.md": "# B\nsecond\n",
+		"docs/skip.txt":   "skipped",
+		"notes.txt":       "notes",
+	})
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:         []string{"docs/**/*.md", "notes.txt"},
+		OutputFile:     "out.jsonl",
+		Method:         config.Markdown,
+		MarkdownLevels: []int{1},
+		IDScheme:       config.IDOrdinal,
+	}
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{
+		{ID: "docs/a.md#0", Source: "docs/a.md", Text: "# A\nfirst\n", SHA256: "9a7bdd983cada0afd03003e3282b9cddc522d7067088c5241d8cf7eb068d36c5"},
+		{ID: "docs/guide/b.md#0", Source: "docs/guide/b.md", Text: "# B\nsecond\n", SHA256: "03b7b14e63e68aad5f7297c588c239a597ad5019c7ed25587d62e1bd8ca50c5f"},
+		{ID: "notes.txt#0", Source: "notes.txt", Text: "notes", SHA256: "ab5aa97074c454a0632057e704220d9a6678fbf773a0a5806fc09b8173b07309"},
+	}, readChunks(t, "out.jsonl"))
+}
+
+func TestOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.txt":       "first",
+		"docs/guide/b.txt": "second",
+	})
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:    []string{"docs"},
+		OutputDir: "out",
+		Method:    config.Char,
+		ChunkSize: 100,
+	}
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{{Source: "docs/a.txt", Text: "first"}}, readChunks(t, "out/a.txt.jsonl"))
+	assert.Equal(t, []chopper.Chunk{{Source: "docs/guide/b.txt", Text: "second"}}, readChunks(t, "out/guide/b.txt.jsonl"))
+}
+
+func TestOutputFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.txt":       "first",
+		"docs/guide/b.txt": "second",
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputFile = "out.json"
+	cfg.Workers = 2
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	data, err := os.ReadFile("out.json")
+	require.NoError(t, err)
+	assert.Equal(t, "[\n{\"source\":\"docs/a.txt\",\"chunk\":\"first\"},\n{\"source\":\"docs/guide/b.txt\",\"chunk\":\"second\"}\n]\n", string(data))
+
+	cfg = config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputDir = "out"
+	cfg.OutputFormat = config.FormatCSV
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	data, err = os.ReadFile("out/guide/b.txt.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "source,chunk\ndocs/guide/b.txt,second\n", string(data))
+}
+
+func TestSQLiteOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs/a.txt": "one two three", "docs/b.txt": "four"})
+	chdir(t, dir)
+
+	run := func() {
+		cfg := config.NewConfig()
+		cfg.Inputs = []string{"docs"}
+		cfg.OutputFile = "chunks.db"
+		cfg.Method = config.Word
+		cfg.ChunkSize = 2
+		require.NoError(t, cfg.Validate())
+		require.NoError(t, NewRunner(cfg).Run())
+	}
+	run()
+	require.NoError(t, os.WriteFile("docs/a.txt", []byte("five"), 0o644))
+	run()
+
+	db, err := sql.Open("sqlite", "chunks.db")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM chunks`).Scan(&count))
+	assert.Equal(t, 2, count)
+	var source string
+	require.NoError(t, db.QueryRow(`SELECT source FROM chunks WHERE rowid IN (SELECT rowid FROM chunks_fts WHERE chunks_fts MATCH 'five')`).Scan(&source))
+	assert.Equal(t, "docs/a.txt", source)
+}
+
+// embeddingServer answers embeddings requests with the length of every input
+// as its embedding.
+func embeddingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "nomic-embed-text", request.Model)
+
+		data := make([]map[string]any, len(request.Input))
+		for i, input := range request.Input {
+			data[i] = map[string]any{"index": i, "embedding": []float32{float32(len(input)), 0.5}}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"data": data}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSQLiteOutputShrinkingInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/r.jsonl": "{\"text\": \"one\"}\n{\"text\": \"two\"}\n{\"text\": \"three\"}\n",
+		"docs/b.txt":   "four",
+	})
+	chdir(t, dir)
+
+	run := func() {
+		cfg := config.NewConfig()
+		cfg.Inputs = []string{"docs/r.jsonl"}
+		cfg.InputFormat = config.InputJSONL
+		cfg.OutputFile = "r.sqlite"
+		require.NoError(t, cfg.Validate())
+		require.NoError(t, NewRunner(cfg).Run())
+
+		cfg = config.NewConfig()
+		cfg.Inputs = []string{"docs/b.txt"}
+		cfg.OutputFile = "r.sqlite"
+		require.NoError(t, cfg.Validate())
+		require.NoError(t, NewRunner(cfg).Run())
+	}
+	run()
+	writeFiles(t, dir, map[string]string{"docs/r.jsonl": "{\"text\": \"one\"}\n", "docs/b.txt": ""})
+	run()
+
+	db, err := sql.Open("sqlite", "r.sqlite")
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT source, text FROM chunks ORDER BY source`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var chunks []string
+	for rows.Next() {
+		var source, text string
+		require.NoError(t, rows.Scan(&source, &text))
+		chunks = append(chunks, source+" "+text)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"docs/r.jsonl:1 one"}, chunks)
+}
+
+func TestEmbed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs/a.txt": "one two three", "docs/b.txt": "four"})
+	chdir(t, dir)
+	server := embeddingServer(t)
+
+	run := func(output string) {
+		cfg := config.NewConfig()
+		cfg.Inputs = []string{"docs"}
+		cfg.OutputFile = output
+		cfg.Method = config.Word
+		cfg.ChunkSize = 1
+		cfg.Workers = 2
+		cfg.Embed = true
+		cfg.EmbedURL = server.URL + "/v1"
+		cfg.EmbedModel = "nomic-embed-text"
+		cfg.EmbedBatchSize = 2
+		require.NoError(t, cfg.Validate())
+		require.NoError(t, NewRunner(cfg).Run())
+	}
+
+	run("out.jsonl")
+	assert.Equal(t, []chopper.Chunk{
+		{Source: "docs/a.txt", Text: "one", Embedding: []float32{3, 0.5}},
+		{Source: "docs/a.txt", Text: "two", Embedding: []float32{3, 0.5}},
+		{Source: "docs/a.txt", Text: "three", Embedding: []float32{5, 0.5}},
+		{Source: "docs/b.txt", Text: "four", Embedding: []float32{4, 0.5}},
+	}, readChunks(t, "out.jsonl"))
+
+	run("chunks.db")
+	db, err := sql.Open("sqlite", "chunks.db")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var embedding []byte
+	require.NoError(t, db.QueryRow(`SELECT embedding FROM chunks WHERE text = 'three'`).Scan(&embedding))
+	assert.Equal(t, []byte{0, 0, 0xa0, 0x40, 0, 0, 0, 0x3f}, embedding)
+}
+
+func TestEmbedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs/a.txt": "one"})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Embed = true
+	cfg.EmbedURL = server.URL
+	require.NoError(t, cfg.Validate())
+	assert.EqualError(t, NewRunner(cfg).Run(), "failed to embed chunks: embeddings request failed with status 401: invalid api key")
+}
+
+func TestOutputDirConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"one/a.txt": "first",
+		"two/a.txt": "second",
+	})
+	chdir(t, dir)
+
+	cfg := &config.Config{
+		Inputs:    []string{"one/a.txt", "two/a.txt"},
+		OutputDir: "out",
+		Method:    config.Char,
+		ChunkSize: 100,
+	}
+	assert.ErrorContains(t, NewRunner(cfg).Run(), "inputs one/a.txt and two/a.txt have the same output file")
+}
+
+func TestNoInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"docs/a.txt": "first"})
+	chdir(t, dir)
+
+	cfg := &config.Config{Inputs: []string{"docs/*.md"}, Method: config.Char, ChunkSize: 100}
+	assert.ErrorContains(t, NewRunner(cfg).Run(), "no input files found")
+}
+
+func TestAutoMethod(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/guide.md":  "# Install\nrun make\n# Usage\nrun chopdoc\n",
+		"docs/main.go":   "package main\n\nfunc main() {\n}\n\nfunc helper() {\n}\n",
+		"docs/notes.txt": "First sentence. Second sentence.",
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Method = config.Auto
+	cfg.ChunkSize = 30
+	cfg.AddMetadata = true
+	cfg.MethodMapList = ".txt=sentence"
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{
+		{Source: "docs/guide.md", Text: "# Install\nrun make\n", Metadata: map[string]any{
+			"Header 1": "Install", "breadcrumb": []any{"Install"}, "h_path": "Install",
+		}},
+		{Source: "docs/guide.md", Text: "# Usage\nrun chopdoc\n", Metadata: map[string]any{
+			"Header 1": "Usage", "breadcrumb": []any{"Usage"}, "h_path": "Usage",
+		}},
+		{Source: "docs/main.go", Text: "package main\n\nfunc main() {\n}\n"},
+		{Source: "docs/main.go", Text: "\nfunc helper() {\n}\n"},
+		{Source: "docs/notes.txt", Text: "First sentence. Second sentence."},
+	}, readChunks(t, "out.jsonl"))
+}
+
+func TestPDFInput(t *testing.T) {
+	pdf, err := os.ReadFile("../tests/example.pdf")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/report.pdf": string(pdf),
+		"docs/notes.txt":  "plain text",
+	})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"docs"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Method = config.Sentence
+	cfg.ChunkSize = 3
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	chunks := readChunks(t, "out.jsonl")
+	require.Len(t, chunks, 3)
+	assert.Equal(t, chopper.Chunk{Source: "docs/notes.txt", Text: "plain text"}, chunks[0])
+	assert.Equal(t, "docs/report.pdf", chunks[1].Source)
+	assert.Equal(t, map[string]any{"page": float64(1), "page_range": []any{float64(1), float64(2)}}, chunks[1].Metadata)
+	assert.Equal(t, chopper.Chunk{
+		Source:   "docs/report.pdf",
+		Text:     "Kerned words are split. Café on the third page.",
+		Metadata: map[string]any{"page": float64(2), "page_range": []any{float64(2), float64(3)}},
+	}, chunks[2])
+}
+
+// docxFile returns a Word document with the parts in the order Word writes
+// them.
+func docxFile(t *testing.T) string {
+	t.Helper()
+
+	var docx bytes.Buffer
+	archive := zip.NewWriter(&docx)
+	f, err := archive.Create("[Content_Types].xml")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`))
+	require.NoError(t, err)
+	f, err = archive.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Offer</w:t></w:r></w:p>
+<w:p><w:r><w:t>Dear reader.</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Salary</w:t></w:r></w:p>
+<w:p><w:r><w:t>Competitive.</w:t></w:r></w:p>
+</w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	return docx.String()
+}
+
+func TestDOCXInput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"letters/offer.docx": docxFile(t)})
+	chdir(t, dir)
+
+	cfg := config.NewConfig()
+	cfg.Inputs = []string{"letters"}
+	cfg.OutputFile = "out.jsonl"
+	cfg.Method = config.Auto
+	cfg.AddMetadata = true
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, NewRunner(cfg).Run())
+
+	assert.Equal(t, []chopper.Chunk{
+		{Source: "letters/offer.docx", Text: "# Offer\n\nDear reader.\n\n", Metadata: map[string]any{
+			"Header 1": "Offer", "breadcrumb": []any{"Offer"}, "h_path": "Offer",
+		}},
+		{Source: "letters/offer.docx", Text: "## Salary\n\nCompetitive.\n", Metadata: map[string]any{
+			"Header 1": "Offer", "Header 2": "Salary", "breadcrumb": []any{"Offer", "Salary"}, "h_path": "Offer > Salary",
+		}},
+	}, readChunks(t, "out.jsonl"))
+}
+
+func TestPipedDOCX(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Piped = true
+	cfg.Method = config.Markdown
+	require.NoError(t, cfg.Validate())
+
+	var out bytes.Buffer
+	require.NoError(t, NewRunner(cfg).chop(context.Background(), cfg, strings.NewReader(docxFile(t)), chopper.NewJSONLSink(&out)))
+	assert.Equal(t, []chopper.Chunk{
+		{Text: "# Offer\n\nDear reader.\n\n"},
+		{Text: "## Salary\n\nCompetitive.\n"},
+	}, parseChunks(t, out.Bytes()))
+}
@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]*Tokenizer)
+)
+
+func init() {
+	// vocabularies are embedded into the binary, nothing is downloaded at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+type Tokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// Get returns the BPE tokenizer for the given encoding name, e.g. cl100k_base.
+// Tokenizers are built once and shared.
+func Get(name string) (*Tokenizer, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if t, ok := cache[name]; ok {
+		return t, nil
+	}
+
+	encoding, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer %s: %w", name, err)
+	}
+
+	t := &Tokenizer{encoding: encoding}
+	cache[name] = t
+
+	return t, nil
+}
+
+// Encode treats special tokens like <|endoftext|> as plain text.
+func (t *Tokenizer) Encode(text string) []int {
+	return t.encoding.EncodeOrdinary(text)
+}
+
+func (t *Tokenizer) Decode(tokens []int) string {
+	return t.encoding.Decode(tokens)
+}
+
+func (t *Tokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
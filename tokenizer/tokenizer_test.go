@@ -0,0 +1,65 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name      string
+		encoding  string
+		text      string
+		wantCount int
+		wantErr   string
+	}{
+		{
+			name:      "cl100k_base",
+			encoding:  "cl100k_base",
+			text:      "hello world",
+			wantCount: 2,
+		},
+		{
+			name:      "o200k_base",
+			encoding:  "o200k_base",
+			text:      "hello world",
+			wantCount: 2,
+		},
+		{
+			name:      "special tokens are plain text",
+			encoding:  "cl100k_base",
+			text:      "<|endoftext|>",
+			wantCount: 7,
+		},
+		{
+			name:     "unknown encoding",
+			encoding: "unknown",
+			wantErr:  "failed to load tokenizer unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := Get(tt.encoding)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, tok.Count(tt.text))
+			assert.Equal(t, tt.text, tok.Decode(tok.Encode(tt.text)))
+		})
+	}
+}
+
+func TestGetCached(t *testing.T) {
+	a, err := Get("cl100k_base")
+	require.NoError(t, err)
+	b, err := Get("cl100k_base")
+	require.NoError(t, err)
+
+	assert.Same(t, a, b)
+}